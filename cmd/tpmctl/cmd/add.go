@@ -2,37 +2,31 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/urfave/cli/v3"
 )
 
-//nolint:dupl // PIN and passphrase commands have intentionally similar structure
 func (a App) newAddPassphraseCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "add-passphrase",
 		Usage: "Add passphrase authentication",
+		Flags: generateFlags("passphrase", &cli.IntFlag{
+			Name:  "words",
+			Usage: "Number of words of the generated passphrase",
+			Value: defaultPassphraseWords,
+		}),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Ensure that the user's effective ID is root
 			if !a.isUserRoot() {
-				return fmt.Errorf("this command requires elevated privileges. Please run with sudo")
+				return ErrRootRequired
 			}
 
-			newPassphrase, err := a.tui.ReadUserSecret("Enter new passphrase: ")
+			newPassphrase, err := a.newPassphrase(ctx, cmd)
 			if err != nil {
 				return err
 			}
 
-			confirmPassphrase, err := a.tui.ReadUserSecret("Confirm new passphrase: ")
-			if err != nil {
-				return err
-			}
-
-			if newPassphrase != confirmPassphrase {
-				return fmt.Errorf("passphrase confirmation does not match")
-			}
-
-			stop := a.tui.Spin("Adding passphrase...")
+			ctx, stop := a.spinChange(ctx, cmd, "Adding passphrase...")
 			defer stop()
 
 			if err := a.tpm.AddPassphrase(ctx, newPassphrase); err != nil {
@@ -40,38 +34,32 @@ func (a App) newAddPassphraseCmd() *cli.Command {
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "Passphrase added successfully")
-			return nil
+			return a.reportChanged(cmd, "Passphrase added successfully")
 		},
 	}
 }
 
-//nolint:dupl // PIN and passphrase commands have intentionally similar structure
 func (a App) newAddPINCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "add-pin",
 		Usage: "Add PIN authentication",
+		Flags: generateFlags("PIN", &cli.IntFlag{
+			Name:  "length",
+			Usage: "Number of digits of the generated PIN",
+			Value: defaultPINLength,
+		}),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Ensure that the user's effective ID is root
 			if !a.isUserRoot() {
-				return fmt.Errorf("this command requires elevated privileges. Please run with sudo")
+				return ErrRootRequired
 			}
 
-			newPIN, err := a.tui.ReadUserSecret("Enter new PIN: ")
+			newPIN, err := a.newPIN(ctx, cmd)
 			if err != nil {
 				return err
 			}
 
-			confirmPIN, err := a.tui.ReadUserSecret("Confirm new PIN: ")
-			if err != nil {
-				return err
-			}
-
-			if newPIN != confirmPIN {
-				return fmt.Errorf("PIN confirmation does not match")
-			}
-
-			stop := a.tui.Spin("Adding PIN...")
+			ctx, stop := a.spinChange(ctx, cmd, "Adding PIN...")
 			defer stop()
 
 			if err := a.tpm.AddPIN(ctx, newPIN); err != nil {
@@ -79,8 +67,7 @@ func (a App) newAddPINCmd() *cli.Command {
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "PIN added successfully")
-			return nil
+			return a.reportChanged(cmd, "PIN added successfully")
 		},
 	}
 }
@@ -29,16 +29,19 @@ func TestAdd(t *testing.T) {
 	tests := map[string]struct {
 		admineUID    int
 		ttyReadError bool
+		tooWeak      int
 
 		wantErr bool
 	}{
-		"Success": {},
-
-		"Error_on_user_privilege": {admineUID: 1, wantErr: true},
-		"Error_reading_input":     {ttyReadError: true, wantErr: true},
-		"Error_wrong_auth_mode":   {wantErr: true},
-		"Error_on_validating":     {wantErr: true},
-		"Error_on_adding":         {wantErr: true},
+		"Success":                        {},
+		"Success_after_too_weak_secrets": {tooWeak: 2},
+
+		"Error_on_user_privilege":   {admineUID: 1, wantErr: true},
+		"Error_reading_input":       {ttyReadError: true, wantErr: true},
+		"Error_wrong_auth_mode":     {wantErr: true},
+		"Error_on_validating":       {wantErr: true},
+		"Error_on_adding":           {wantErr: true},
+		"Error_on_too_weak_secrets": {tooWeak: 3, wantErr: true},
 	}
 	for _, command := range commands {
 		for name, tc := range tests {
@@ -68,7 +71,7 @@ func TestAdd(t *testing.T) {
 				done := make(chan struct{})
 				go func() {
 					defer close(done)
-					for range 2 {
+					for range tc.tooWeak + 2 {
 						fmt.Fprintln(ptmx, input)
 					}
 				}()
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/canonical/snap-tpmctl/internal/fdestate"
+	"github.com/urfave/cli/v3"
+)
+
+func (a App) newApplyCmd() *cli.Command {
+	var path string
+
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "Reconcile the FDE state of the system with a YAML state file",
+		Description: "Exits with 0 when the system already matches the state file, 2 when changes were planned or applied, " +
+			"and 1 on error, so that applying the same state file again is a no-op.",
+		Suggest: true,
+		Flags: append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  "plan",
+				Usage: "Only show the changes which would be applied",
+			},
+			verifyRecoveryKeyFlag(),
+		}, escrowFlags()...),
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:        "state-file",
+				UsageText:   "<state-file>",
+				Destination: &path,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if path == "" {
+				return errors.New("missing state file")
+			}
+
+			// Ensure that the user's effective ID is root
+			if !cmd.Bool("plan") && !a.isUserRoot() {
+				return ErrRootRequired
+			}
+
+			state, err := readStateFile(path)
+			if err != nil {
+				return err
+			}
+
+			plan, err := a.planState(ctx, state)
+			if err != nil {
+				return err
+			}
+
+			if format := outputFormat(cmd); format != formatTable && cmd.Bool("plan") {
+				if err := writeStructured(a.tui.Writer(), format, planOutput{
+					SchemaVersion: schemaVersion,
+					Plan:          plan,
+				}); err != nil {
+					return err
+				}
+			} else {
+				a.displayPlan(plan)
+			}
+
+			if len(plan.Conflicts) > 0 {
+				return errors.New("the state file can't be applied: resolve the conflicts first")
+			}
+			if !plan.HasChanges() {
+				return nil
+			}
+			if cmd.Bool("plan") {
+				return errChanged
+			}
+
+			if err := a.applyPlan(ctx, cmd, plan); err != nil {
+				return err
+			}
+			if isDryRun(cmd) {
+				return a.dryRunDone()
+			}
+
+			return errChanged
+		},
+	}
+}
+
+// readStateFile reads and validates the state file at path.
+func readStateFile(path string) (fdestate.State, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fdestate.State{}, fmt.Errorf("failed to read state file: %w", err)
+	}
+	defer f.Close()
+
+	return fdestate.Parse(f)
+}
+
+// planState compares the system with the state and returns the changes to make.
+func (a App) planState(ctx context.Context, state fdestate.State) (fdestate.Plan, error) {
+	status, err := a.tpm.FdeStatus(ctx)
+	if err != nil {
+		return fdestate.Plan{}, err
+	}
+
+	volumes, err := a.tpm.ListVolumeInfo(ctx)
+	if err != nil {
+		return fdestate.Plan{}, err
+	}
+
+	return fdestate.Diff(state, status, volumes)
+}
+
+// displayPlan prints the plan, one line per change.
+func (a App) displayPlan(plan fdestate.Plan) {
+	w := a.tui.Writer()
+
+	for _, action := range plan.Actions {
+		symbol := "~"
+		if action.Kind == fdestate.CreateRecoveryKey {
+			symbol = "+"
+		}
+		fmt.Fprintf(w, "%s %s\n", symbol, action)
+	}
+	for _, conflict := range plan.Conflicts {
+		fmt.Fprintf(w, "! %s\n", conflict)
+	}
+	for _, name := range plan.Unmanaged {
+		fmt.Fprintf(w, "= recovery key %q is not in the state file and is kept\n", name)
+	}
+
+	if !plan.HasChanges() {
+		fmt.Fprintln(w, "No changes: the system matches the state file")
+	}
+}
+
+// applyPlan makes the changes of the plan in order. Secrets are read before any change,
+// so that the system is not left half reconciled if reading them is cancelled.
+func (a App) applyPlan(ctx context.Context, cmd *cli.Command, plan fdestate.Plan) error {
+	if err := a.checkEscrow(cmd); err != nil {
+		return err
+	}
+
+	var secret string
+	for _, action := range plan.Actions {
+		if action.Kind != fdestate.SetAuth || action.Auth == fdestate.AuthNone {
+			continue
+		}
+
+		kind := "PIN"
+		if action.Auth == fdestate.AuthPassphrase {
+			kind = "passphrase"
+		}
+
+		var err error
+		if secret, err = a.readNewSecret(ctx, kind); err != nil {
+			return err
+		}
+	}
+
+	for i, action := range plan.Actions {
+		if err := a.applyAction(ctx, cmd, action, secret); err != nil {
+			return fmt.Errorf("%w (%d of %d changes applied)", err, i, len(plan.Actions))
+		}
+	}
+
+	if isDryRun(cmd) {
+		return nil
+	}
+
+	if len(plan.Actions) == 1 {
+		fmt.Fprintln(a.tui.Writer(), "Applied 1 change")
+	} else {
+		fmt.Fprintf(a.tui.Writer(), "Applied %d changes\n", len(plan.Actions))
+	}
+
+	return nil
+}
+
+// applyAction makes a single change of the plan.
+func (a App) applyAction(ctx context.Context, cmd *cli.Command, action fdestate.Action, secret string) error {
+	switch action.Kind {
+	case fdestate.CreateRecoveryKey:
+		sctx, stop := a.spinChange(ctx, cmd, fmt.Sprintf("Creating recovery key %q...", action.RecoveryKey))
+		recoveryKey, err := a.tpm.CreateKey(sctx, action.RecoveryKey, action.ContainerRoles)
+		stop()
+		if err != nil {
+			return err
+		}
+		if isDryRun(cmd) {
+			return nil
+		}
+
+		return a.handOverRecoveryKey(ctx, cmd, action.RecoveryKey, recoveryKey, action.ContainerRoles)
+
+	case fdestate.SetAuth:
+		sctx, stop := a.spinChange(ctx, cmd, fmt.Sprintf("Changing authentication to %s...", action.Auth))
+		defer stop()
+
+		var err error
+		switch action.Auth {
+		case fdestate.AuthPIN:
+			err = a.tpm.AddPIN(sctx, secret)
+		case fdestate.AuthPassphrase:
+			err = a.tpm.AddPassphrase(sctx, secret)
+		case fdestate.AuthNone:
+			// Both remove the authentication of the platform keys.
+			if action.CurrentAuth == fdestate.AuthPassphrase {
+				err = a.tpm.RemovePassphrase(sctx)
+			} else {
+				err = a.tpm.RemovePIN(sctx)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		stop()
+		if isDryRun(cmd) {
+			return nil
+		}
+
+		fmt.Fprintf(a.tui.Writer(), "Authentication changed to %s\n", action.Auth)
+		return nil
+	}
+
+	return fmt.Errorf("unsupported change %q", action.Kind)
+}
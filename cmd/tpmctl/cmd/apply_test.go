@@ -0,0 +1,122 @@
+package cmd_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	const (
+		matchingState = "auth: passphrase\nrecovery-keys:\n  - name: default-recovery\n"
+		changedState  = "auth: pin\nrecovery-keys:\n  - name: default-recovery\n  - name: ops-2026\n"
+	)
+
+	tests := map[string]struct {
+		state     string
+		noState   bool
+		extraArgs []string
+		answers   []string
+		nonRoot   bool
+
+		wantStatus   int
+		wantRequests bool
+		wantErr      bool
+	}{
+		"Success_when_system_matches_state": {state: matchingState},
+		"Success_planning_changes":          {state: changedState, extraArgs: []string{"--plan"}, nonRoot: true, wantStatus: 2},
+		"Success_planning_changes_in_json":  {state: changedState, extraArgs: []string{"--plan", "--format", "json"}, wantStatus: 2},
+		"Success_planning_changes_in_yaml":  {state: changedState, extraArgs: []string{"--plan", "--format", "yaml"}, wantStatus: 2},
+		"Success_planning_without_changes":  {state: matchingState, extraArgs: []string{"--plan"}},
+		"Success_applying_changes":          {state: changedState, answers: []string{"12345", "12345", ""}, wantStatus: 2, wantRequests: true},
+		"Success_removing_authentication":   {state: "auth: none\n", wantStatus: 2, wantRequests: true},
+
+		"Error_without_state_file":              {noState: true, wantErr: true},
+		"Error_on_missing_state_file":           {wantErr: true},
+		"Error_on_invalid_state_file":           {state: "auth: pin\nunknown: true\n", wantErr: true},
+		"Error_when_not_root":                   {state: changedState, nonRoot: true, wantErr: true},
+		"Error_on_conflicts":                    {state: "recovery-keys:\n  - name: default-recovery\n    container-roles: [system-data]\n", wantErr: true},
+		"Error_when_FDE_is_disabled":            {state: matchingState, wantErr: true},
+		"Error_on_escrow_dir_without_recipient": {state: changedState, extraArgs: []string{"--escrow-dir", "."}, wantErr: true},
+		"Error_when_applying_fails":             {state: changedState, answers: []string{"12345", "12345"}, wantRequests: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			args := append([]string{"apply", "--verify=false"}, tc.extraArgs...)
+			if !tc.noState {
+				path := filepath.Join(t.TempDir(), "state.yaml")
+				if tc.state != "" {
+					err := os.WriteFile(path, []byte(tc.state), 0o600)
+					is.NoErr(err) // Setup: could not write state file
+				}
+				args = append(args, path)
+			}
+
+			euid := 0
+			if tc.nonRoot {
+				euid = 1000
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+				cmdtestutils.WithEuid(euid),
+			)
+
+			err = app.Run(ctx)
+
+			var posts int
+			for _, r := range c.Requests {
+				if r.Method != "GET" {
+					posts++
+				}
+			}
+			is.Equal(posts > 0, tc.wantRequests) // Changes are only made when applying
+
+			// Changes are reported with the exit status, which is not an error.
+			var status cmd.ExitStatusError
+			if errors.As(err, &status) {
+				err = nil
+			}
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(int(status), tc.wantStatus) // Exit status reports whether the system did not match
+
+			is.True(logs.Len() == 0) // No logs printed by default
+
+			golden.CheckOrUpdate(t, out.String()) // TestApply returns the expected output
+		})
+	}
+}
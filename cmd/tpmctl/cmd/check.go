@@ -13,6 +13,10 @@ func (a App) newCheckCmd() *cli.Command {
 		Name:    "check-recovery-key",
 		Usage:   "Check recovery key",
 		Suggest: true,
+		Flags: []cli.Flag{
+			containerRoleFlag(),
+		},
+		ShellComplete: a.shellCompleteWithContainerRoles,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			key, err := a.tui.ReadRecoveryKey()
 			if err != nil {
@@ -22,7 +26,7 @@ func (a App) newCheckCmd() *cli.Command {
 			stop := a.tui.Spin("Checking recovery key...")
 			defer stop()
 
-			ok, err := a.tpm.CheckKey(ctx, key)
+			ok, err := a.tpm.CheckKey(ctx, key, containerRoles(cmd))
 			if err != nil {
 				return err
 			}
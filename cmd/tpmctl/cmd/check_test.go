@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -21,17 +22,24 @@ func TestCheck(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		key          string
-		ttyReadError bool
+		key            string
+		containerRoles []string
+		ttyReadError   bool
 
-		wantErr bool
+		wantInBody string
+		wantErr    bool
 	}{
 		"Success_checking_recovery_key":            {},
 		"Success_even_with_invalid_recovery_key":   {},
 		"Success_even_with_incorrect_recovery_key": {key: "incorrect"},
-
-		"Error_reading_input":         {ttyReadError: true, wantErr: true},
-		"Error_checking_recovery_key": {wantErr: true},
+		"Success_checking_on_container_roles": {
+			containerRoles: []string{"system-save", "system-data", "system-save"},
+			wantInBody:     `"container-roles":["system-data","system-save"]`,
+		},
+
+		"Error_reading_input":             {ttyReadError: true, wantErr: true},
+		"Error_checking_recovery_key":     {wantErr: true},
+		"Error_on_unknown_container_role": {containerRoles: []string{"system-boot"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -41,7 +49,10 @@ func TestCheck(t *testing.T) {
 			is := is.New(t)
 			ctx, logs := testutils.TestLoggerWithBuffer(t)
 
-			command := "check-recovery-key"
+			args := []string{"check-recovery-key"}
+			for _, role := range tc.containerRoles {
+				args = append(args, "--container-role", role)
+			}
 			if tc.key == "" {
 				tc.key = "11272-47509-28031-54818-41671-38673-11053-06376"
 			}
@@ -68,7 +79,7 @@ func TestCheck(t *testing.T) {
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 			app := cmd.New(
 				cmdtestutils.WithSnapTPM(s),
-				cmdtestutils.WithArgs(command),
+				cmdtestutils.WithArgs(args...),
 				cmdtestutils.WithTui(tui),
 			)
 
@@ -79,6 +90,12 @@ func TestCheck(t *testing.T) {
 
 			is.True(logs.Len() == 0) // No logs printed by default
 
+			if tc.wantInBody != "" {
+				is.True(slices.ContainsFunc(c.Requests, func(r snapdtestutils.RecordedRequest) bool {
+					return strings.Contains(r.Body, tc.wantInBody)
+				})) // Container roles are sent to snapd
+			}
+
 			golden.CheckOrUpdate(t, out.String()) // TestCheck returns the expected output
 		})
 	}
@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
+	"github.com/canonical/snap-tpmctl/internal/escrow"
 	"github.com/canonical/snap-tpmctl/internal/log"
 	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/canonical/snap-tpmctl/internal/tui"
@@ -21,6 +26,13 @@ type option struct {
 	euid int
 	tpm  tpm.SnapTPM
 	tui  tui.Tui
+	rand io.Reader
+
+	escrowQueueDir      string
+	escrowClientOptions []escrow.ClientOption
+
+	// auditLog records the audited workflows, in the system log when nil.
+	auditLog io.Writer
 }
 
 // Option is a functional option for configuring the App.
@@ -33,6 +45,9 @@ func New(args ...Option) App {
 		euid: os.Geteuid(),
 		tpm:  tpm.New(),
 		tui:  tui.New(os.Stdin, os.Stdout),
+		rand: rand.Reader,
+
+		escrowQueueDir: defaultEscrowQueueDir(),
 	}
 	for _, f := range args {
 		f(&o)
@@ -43,6 +58,21 @@ func New(args ...Option) App {
 	}
 }
 
+// ErrRootRequired is returned by the commands which must be run as root.
+var ErrRootRequired = errors.New("this command requires elevated privileges. Please run with sudo")
+
+// ExitStatusError is returned by commands succeeding with a non-zero exit status, to report their outcome to scripts.
+// It is not an error to report.
+type ExitStatusError int
+
+func (s ExitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// errChanged is the exit status of commands which changed the system, or would have changed it in dry-run mode,
+// when it is worth telling apart from a no-op.
+const errChanged ExitStatusError = 2
+
 // Run is the main entry point of the app.
 func (a App) Run(ctx context.Context) error {
 	root := a.newRootCmd()
@@ -59,11 +89,13 @@ var version = "dev"
 
 func (a App) newRootCmd() cli.Command {
 	var verbosity int
+	releaseSecretSource := func() error { return nil }
 
 	return cli.Command{
 		Name:                   "snap-tpmctl",
 		Usage:                  "Ubuntu TPM and FDE management tool",
 		Version:                version,
+		Writer:                 a.tui.Writer(),
 		UseShortOptionHandling: true,
 		EnableShellCompletion:  true,
 		ConfigureShellCompletionCommand: func(cmd *cli.Command) {
@@ -79,10 +111,15 @@ func (a App) newRootCmd() cli.Command {
 		},
 		HideVersion: true,
 		Commands: []*cli.Command{
+			a.newActivateVolumeCmd(),
 			a.newAddPINCmd(),
 			a.newAddPassphraseCmd(),
+			a.newApplyCmd(),
 			a.newCreateKeyCmd(),
 			a.newCheckCmd(),
+			a.newCombineKeyCmd(),
+			a.newDeactivateVolumeCmd(),
+			a.newEscrowCmd(),
 			a.newGetLuksKeyFromRecoveryKeyCmd(),
 			a.newListAllCmd(),
 			a.newListPassphraseCmd(),
@@ -94,11 +131,12 @@ func (a App) newRootCmd() cli.Command {
 			a.newRegenerateKeyCmd(),
 			a.newRemovePassphraseCmd(),
 			a.newRemovePINCmd(),
+			a.newResetAuthCmd(),
 			a.newStatusCmd(),
 			a.newUnmountVolumeCmd(),
 			newVersionCmd(),
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.BoolFlag{
 				Name:    "verbosity",
 				Usage:   "Increase verbosity level",
@@ -107,11 +145,38 @@ func (a App) newRootCmd() cli.Command {
 					Count: &verbosity,
 				},
 			},
-		},
+			&cli.StringFlag{
+				Name:      "format",
+				Usage:     "Output format of read-only commands: table, json or yaml",
+				Value:     formatTable,
+				Validator: validateOutputFormat,
+			},
+			&cli.StringFlag{
+				Name:      "on-interrupt",
+				Usage:     "What to do with a running operation when interrupted: abort, detach or ask",
+				Value:     interruptAbort,
+				Validator: validateInterruptAction,
+			},
+			dryRunFlag(),
+		}, secretInputFlags()...),
 		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 			setupLogging(ctx, verbosity)
+
+			release, err := a.setupSecretSource(ctx, cmd)
+			if err != nil {
+				return ctx, err
+			}
+			releaseSecretSource = release
+
+			if isDryRun(cmd) {
+				ctx = a.withDryRun(ctx)
+			}
+
 			return ctx, nil
 		},
+		After: func(ctx context.Context, cmd *cli.Command) error {
+			return releaseSecretSource()
+		},
 	}
 }
 
@@ -1,9 +1,7 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
-	"fmt"
 
 	"github.com/urfave/cli/v3"
 )
@@ -14,6 +12,12 @@ func (a App) newCreateKeyCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "create-recovery-key",
 		Usage: "Create a new recovery key",
+		Flags: append([]cli.Flag{
+			containerRoleFlag(),
+			verifyRecoveryKeyFlag(),
+			splitFlag(),
+		}, recoveryKeyExportFlags()...),
+		ShellComplete: a.shellCompleteWithContainerRoles,
 		Arguments: []cli.Argument{
 			&cli.StringArg{
 				Name:        "key-id",
@@ -22,24 +26,32 @@ func (a App) newCreateKeyCmd() *cli.Command {
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			stop := a.tui.Spin("Generating recovery key...")
+			if err := checkSplit(cmd); err != nil {
+				return err
+			}
+			if err := a.checkRecoveryKeyExport(cmd); err != nil {
+				return err
+			}
+
+			ctx, stop := a.spinChange(ctx, cmd, "Generating recovery key...")
 			defer stop()
 
-			recoveryKey, err := a.tpm.CreateKey(ctx, recoveryKeyName)
+			recoveryKey, err := a.tpm.CreateKey(ctx, recoveryKeyName, containerRoles(cmd))
 			if err != nil {
 				return err
 			}
 
 			stop()
 
-			fmt.Fprintf(a.tui.Writer(), "Recovery Key: %s\n", recoveryKey)
+			if isDryRun(cmd) {
+				return a.dryRunDone()
+			}
 
-			// Wait for user to confirm by pressing Enter
-			fmt.Fprint(a.tui.Writer(), "Save the recovery key somewhere safe. Press Enter to continue...")
-			_, _ = bufio.NewReader(a.tui.Reader()).ReadString('\n')
-			a.tui.ClearPreviousLines(2)
+			if cmd.String("split") != "" {
+				return a.handOverRecoveryKeyShares(cmd, recoveryKey)
+			}
 
-			return nil
+			return a.handOverRecoveryKey(ctx, cmd, recoveryKeyName, recoveryKey, recoveryKeyContainerRoles(cmd))
 		},
 	}
 }
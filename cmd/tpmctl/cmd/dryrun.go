@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/urfave/cli/v3"
+)
+
+// dryRunFlag only shows the requests which would change the system.
+func dryRunFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name: "dry-run",
+		Usage: "Run the checks of the command but only show the requests which would change the system, with secrets redacted. " +
+			"Exits with 2 when the command would make changes",
+	}
+}
+
+// isDryRun returns true if the command must not change the system.
+func isDryRun(cmd *cli.Command) bool {
+	return cmd.Bool("dry-run")
+}
+
+// withDryRun returns a context on which the requests changing the system are printed instead of being sent to snapd.
+func (a App) withDryRun(ctx context.Context) context.Context {
+	return snapd.WithDryRun(ctx, func(r snapd.Request) {
+		fmt.Fprintf(a.tui.Writer(), "Would send %s %s: %s\n", r.Method, r.Path, r.Body)
+	})
+}
+
+// dryRunDone ends a command run in dry-run mode once its changes were shown.
+// The exit status tells that the command would have changed the system.
+func (a App) dryRunDone() error {
+	fmt.Fprintln(a.tui.Writer(), "Dry run: no change was made")
+	return errChanged
+}
+
+// reportChanged prints msg once the command changed the system, or ends the dry run.
+func (a App) reportChanged(cmd *cli.Command, msg string) error {
+	if isDryRun(cmd) {
+		return a.dryRunDone()
+	}
+
+	fmt.Fprintln(a.tui.Writer(), msg)
+	return nil
+}
+
+// checkDryRunSupported rejects dry-run mode for the commands changing the system without going through snapd.
+func checkDryRunSupported(cmd *cli.Command) error {
+	if isDryRun(cmd) {
+		return fmt.Errorf("--dry-run is not supported by %s", cmd.Name)
+	}
+	return nil
+}
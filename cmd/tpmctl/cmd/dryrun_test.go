@@ -0,0 +1,112 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args    []string
+		state   string
+		answers []string
+
+		wantStatus int
+		wantErr    bool
+	}{
+		"Success_adding_pin":                {args: []string{"add-pin"}, answers: []string{"12345", "12345"}, wantStatus: 2},
+		"Success_adding_passphrase":         {args: []string{"add-passphrase"}, answers: []string{"test", "test"}, wantStatus: 2},
+		"Success_removing_pin":              {args: []string{"remove-pin"}, wantStatus: 2},
+		"Success_replacing_pin":             {args: []string{"replace-pin"}, answers: []string{"12345", "54321", "54321"}, wantStatus: 2},
+		"Success_creating_recovery_key":     {args: []string{"create-recovery-key", "my-key"}, wantStatus: 2},
+		"Success_regenerating_recovery_key": {args: []string{"regenerate-recovery-key", "default-recovery"}, wantStatus: 2},
+		"Success_applying_state": {
+			args:       []string{"apply"},
+			state:      "auth: pin\nrecovery-keys:\n  - name: ops-2026\n",
+			answers:    []string{"12345", "12345"},
+			wantStatus: 2,
+		},
+
+		"Error_on_invalid_pin":                  {args: []string{"add-pin"}, answers: []string{"12345", "12345"}, wantErr: true},
+		"Error_on_mount_volume_not_using_snapd": {args: []string{"mount-volume", "/dev/sda1", "/mnt"}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			args := append([]string{"--dry-run"}, tc.args...)
+			if tc.state != "" {
+				path := filepath.Join(t.TempDir(), "state.yaml")
+				err := os.WriteFile(path, []byte(tc.state), 0o600)
+				is.NoErr(err) // Setup: could not write state file
+				args = append(args, path)
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+				cmdtestutils.WithEuid(0),
+			)
+
+			err = app.Run(ctx)
+
+			// Only the requests reading or validating are sent to snapd.
+			for _, r := range c.Requests {
+				if r.Method == "GET" {
+					continue
+				}
+				var body struct {
+					Action string `json:"action"`
+				}
+				is.NoErr(json.Unmarshal([]byte(r.Body), &body))   // Request body is valid JSON
+				is.True(strings.HasPrefix(body.Action, "check-")) // Request does not change the system
+			}
+
+			// Changes which would be made are reported with the exit status, which is not an error.
+			var status cmd.ExitStatusError
+			if errors.As(err, &status) {
+				err = nil
+			}
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(int(status), tc.wantStatus) // Exit status reports whether the command would make changes
+
+			is.True(logs.Len() == 0) // No logs printed by default
+
+			golden.CheckOrUpdate(t, out.String()) // TestDryRun returns the expected output
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/urfave/cli/v3"
+)
+
+// escrowFlags are the flags escrowing a new recovery key to a local vault or to an escrow server.
+func escrowFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "escrow-recipient",
+			Usage: "Escrow the recovery key encrypted to this age public key, like age1..., which also seals the recovery keys queued for the escrow server. Can be repeated",
+		},
+		&cli.StringFlag{
+			Name:      "escrow-dir",
+			Usage:     "Directory where the encrypted recovery key is escrowed to `DIR`",
+			TakesFile: true,
+		},
+	}, escrowServerFlags()...)
+}
+
+// escrowServerFlags are the flags connecting to an escrow server over mutually authenticated TLS.
+func escrowServerFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "escrow-server",
+			Usage: "Escrow the recovery key to the escrow server at `URL`, over https",
+		},
+		&cli.StringFlag{
+			Name:      "escrow-cert",
+			Usage:     "Authenticate to the escrow server with the client certificate of `FILE`, in PEM format",
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "escrow-key",
+			Usage:     "Read the private key of the escrow client certificate from `FILE`, in PEM format",
+			TakesFile: true,
+		},
+		&cli.StringFlag{
+			Name:      "escrow-ca",
+			Usage:     "Verify the escrow server certificate with the authorities of `FILE` instead of the system ones",
+			TakesFile: true,
+		},
+	}
+}
+
+// defaultEscrowQueueDir returns where recovery keys wait until they can be sent to the escrow server.
+func defaultEscrowQueueDir() string {
+	// Confined snaps can only write to their own data directories.
+	if dir := os.Getenv("SNAP_COMMON"); dir != "" {
+		return filepath.Join(dir, "escrow-queue")
+	}
+
+	return "/var/lib/snap-tpmctl/escrow-queue"
+}
+
+// checkEscrow validates the requested escrows, so that it can be checked before creating a key.
+func (a App) checkEscrow(cmd *cli.Command) error {
+	recipients, err := a.escrowRecipients(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.escrowClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if recipients == nil && client == nil {
+		return nil
+	}
+
+	// Recovery keys are queued while the server is unavailable, which requires recipients to seal them to.
+	if client != nil && recipients == nil {
+		return errors.New("--escrow-server requires at least one --escrow-recipient")
+	}
+
+	// The machine ID is part of every escrow record.
+	if _, err := a.tpm.MachineID(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// escrowRecipients returns the recipients of the recovery key escrow, or none if it was not requested.
+// Without an escrow directory, recipients only seal the recovery keys queued for the escrow server.
+func (a App) escrowRecipients(cmd *cli.Command) ([]age.Recipient, error) {
+	keys := cmd.StringSlice("escrow-recipient")
+	dir := cmd.String("escrow-dir")
+
+	if len(keys) == 0 && dir == "" {
+		return nil, nil
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("--escrow-dir requires at least one --escrow-recipient")
+	}
+	if dir == "" && cmd.String("escrow-server") == "" {
+		return nil, errors.New("--escrow-recipient requires --escrow-dir or --escrow-server")
+	}
+
+	recipients, err := escrow.ParseRecipients(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return recipients, nil
+	}
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid escrow directory: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("invalid escrow directory: %s is not a directory", dir)
+	}
+
+	return recipients, nil
+}
+
+// escrowClient returns the client of the escrow server, or none if it was not requested.
+func (a App) escrowClient(cmd *cli.Command) (*escrow.Client, error) {
+	server := cmd.String("escrow-server")
+
+	if server == "" {
+		for _, name := range []string{"escrow-cert", "escrow-key", "escrow-ca"} {
+			if cmd.IsSet(name) {
+				return nil, fmt.Errorf("--%s requires --escrow-server", name)
+			}
+		}
+		return nil, nil
+	}
+
+	if cmd.String("escrow-cert") == "" || cmd.String("escrow-key") == "" {
+		return nil, errors.New("--escrow-server requires --escrow-cert and --escrow-key")
+	}
+
+	return escrow.NewClient(server, cmd.String("escrow-cert"), cmd.String("escrow-key"), cmd.String("escrow-ca"),
+		a.escrowClientOptions...)
+}
+
+// escrowRecoveryKey escrows the recovery key of the container roles with its context to the escrow directory and to the escrow server,
+// if requested. When the server can't be reached or is unavailable, the recovery key is sealed to the escrow recipients and queued
+// until it is synced.
+func (a App) escrowRecoveryKey(ctx context.Context, cmd *cli.Command, name, recoveryKey string, roles []string) error {
+	recipients, err := a.escrowRecipients(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.escrowClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if recipients == nil && client == nil {
+		return nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get hostname for escrow: %w", err)
+	}
+
+	machineID, err := a.tpm.MachineID()
+	if err != nil {
+		return err
+	}
+
+	record := escrow.Record{
+		KeyID:          name,
+		RecoveryKey:    recoveryKey,
+		ContainerRoles: roles,
+		Hostname:       hostname,
+		MachineID:      machineID,
+		Date:           time.Now(),
+	}
+
+	var errs []error
+
+	if cmd.String("escrow-dir") != "" {
+		if path, err := escrow.WriteFile(cmd.String("escrow-dir"), record, recipients); err != nil {
+			errs = append(errs, err)
+		} else {
+			fmt.Fprintf(a.tui.Writer(), "Recovery key escrowed to %s\n", path)
+		}
+	}
+
+	if client != nil {
+		if err := client.Send(ctx, record); errors.Is(err, escrow.ErrUnavailable) {
+			log.Warn(ctx, "%v", err)
+
+			path, qErr := escrow.NewQueue(a.escrowQueueDir).Add(record, recipients)
+			if qErr != nil {
+				errs = append(errs, err, qErr)
+			} else {
+				fmt.Fprintf(a.tui.Writer(), "Recovery key could not be escrowed to the server and is queued to %s: run 'escrow sync' to send it\n", path)
+			}
+		} else if err != nil {
+			errs = append(errs, err)
+		} else {
+			fmt.Fprintf(a.tui.Writer(), "Recovery key escrowed to %s\n", client.Endpoint())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a App) newEscrowCmd() *cli.Command {
+	return &cli.Command{
+		Name:    "escrow",
+		Usage:   "Manage escrowed recovery keys",
+		Suggest: true,
+		Commands: []*cli.Command{
+			a.newEscrowDecryptCmd(),
+			a.newEscrowSyncCmd(),
+		},
+	}
+}
+
+func (a App) newEscrowDecryptCmd() *cli.Command {
+	var path string
+
+	return &cli.Command{
+		Name:    "decrypt",
+		Usage:   "Decrypt an escrowed recovery key",
+		Suggest: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "identity",
+				Aliases:   []string{"i"},
+				Usage:     "Read the age private key of an escrow recipient from `FILE`",
+				Required:  true,
+				TakesFile: true,
+			},
+		},
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:        "escrow-file",
+				UsageText:   "<escrow-file>",
+				Destination: &path,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if path == "" {
+				return errors.New("missing escrow file")
+			}
+
+			record, err := openEscrowFile(path, cmd.String("identity"))
+			if err != nil {
+				return err
+			}
+
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, escrowRecordOutput{
+					SchemaVersion: schemaVersion,
+					Record:        record,
+				})
+			}
+
+			return a.tui.DisplayTable(nil, [][]string{
+				{"Key ID:", record.KeyID},
+				{"Hostname:", record.Hostname},
+				{"Machine ID:", record.MachineID},
+				{"Container roles:", strings.Join(record.ContainerRoles, ", ")},
+				{"Date:", record.Date.Format(time.RFC3339)},
+				{"Recovery Key:", record.RecoveryKey},
+			}, true)
+		},
+	}
+}
+
+func (a App) newEscrowSyncCmd() *cli.Command {
+	return &cli.Command{
+		Name:    "sync",
+		Usage:   "Send the recovery keys queued while the escrow server was unreachable",
+		Suggest: true,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:      "identity",
+				Aliases:   []string{"i"},
+				Usage:     "Open the queued recovery keys with the age private key of an escrow recipient read from `FILE`",
+				Required:  true,
+				TakesFile: true,
+			},
+		}, escrowServerFlags()...),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			// Ensure that the user's effective ID is root
+			if !a.isUserRoot() {
+				return ErrRootRequired
+			}
+
+			client, err := a.escrowClient(cmd)
+			if err != nil {
+				return err
+			}
+			if client == nil {
+				return errors.New("missing escrow server: --escrow-server is required")
+			}
+
+			identities, err := readEscrowIdentities(cmd.String("identity"))
+			if err != nil {
+				return err
+			}
+
+			q := escrow.NewQueue(a.escrowQueueDir)
+			pending, err := q.Pending()
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				fmt.Fprintln(a.tui.Writer(), "No recovery key is queued for escrow")
+				return nil
+			}
+
+			if isDryRun(cmd) {
+				fmt.Fprintf(a.tui.Writer(), "Would escrow %d queued recovery keys to %s\n", len(pending), client.Endpoint())
+				return a.dryRunDone()
+			}
+
+			sent, err := q.Sync(ctx, client, identities)
+			fmt.Fprintf(a.tui.Writer(), "Escrowed %d of %d queued recovery keys to %s\n", sent, len(pending), client.Endpoint())
+			if err != nil {
+				return fmt.Errorf("recovery keys still queued in %s:\n%w", q.Dir(), err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// readEscrowIdentities reads the age identities of the identity file at path.
+func readEscrowIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read escrow identity: %w", err)
+	}
+	defer f.Close()
+
+	return escrow.ParseIdentities(f)
+}
+
+// openEscrowFile decrypts the escrow file at path with the identities of identityPath.
+func openEscrowFile(path, identityPath string) (escrow.Record, error) {
+	identities, err := os.Open(identityPath)
+	if err != nil {
+		return escrow.Record{}, fmt.Errorf("failed to read escrow identity: %w", err)
+	}
+	defer identities.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return escrow.Record{}, fmt.Errorf("failed to read escrow file: %w", err)
+	}
+	defer f.Close()
+
+	return escrow.Open(f, identities)
+}
@@ -0,0 +1,505 @@
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	escrowtestutils "github.com/canonical/snap-tpmctl/internal/escrow/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestEscrow(t *testing.T) {
+	t.Parallel()
+
+	const machineID = "0123456789abcdef0123456789abcdef"
+
+	tests := map[string]struct {
+		command          string
+		recipients       int
+		invalidRecipient bool
+		noDir            bool
+		dirIsFile        bool
+		noMachineID      bool
+
+		wantErr bool
+	}{
+		"Success_escrowing_created_recovery_key":     {recipients: 1},
+		"Success_escrowing_regenerated_recovery_key": {command: "regenerate-recovery-key", recipients: 1},
+		"Success_escrowing_to_several_recipients":    {recipients: 2},
+
+		"Error_without_escrow_recipient":      {wantErr: true},
+		"Error_without_escrow_dir":            {recipients: 1, noDir: true, wantErr: true},
+		"Error_on_invalid_escrow_recipient":   {recipients: 1, invalidRecipient: true, wantErr: true},
+		"Error_when_escrow_dir_is_not_a_dir":  {recipients: 1, dirIsFile: true, wantErr: true},
+		"Error_when_machine_id_is_unreadable": {recipients: 1, noMachineID: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			if tc.command == "" {
+				tc.command = "create-recovery-key"
+			}
+
+			root := t.TempDir()
+			if !tc.noMachineID {
+				err := os.MkdirAll(filepath.Join(root, "etc"), 0o750)
+				is.NoErr(err) // Setup: could not create etc directory
+				err = os.WriteFile(filepath.Join(root, "etc", "machine-id"), []byte(machineID+"\n"), 0o600)
+				is.NoErr(err) // Setup: could not write machine-id
+			}
+
+			escrowDir := t.TempDir()
+			args := []string{tc.command, "test", "--verify=false"}
+			if !tc.noDir {
+				dir := escrowDir
+				if tc.dirIsFile {
+					dir = filepath.Join(escrowDir, "file")
+					err := os.WriteFile(dir, nil, 0o600)
+					is.NoErr(err) // Setup: could not create file
+				}
+				args = append(args, "--escrow-dir", dir)
+			}
+
+			var ids []*age.X25519Identity
+			for range tc.recipients {
+				id, err := age.GenerateX25519Identity()
+				is.NoErr(err) // Setup: could not generate identity
+				ids = append(ids, id)
+				args = append(args, "--escrow-recipient", id.Recipient().String())
+			}
+			if tc.invalidRecipient {
+				args = append(args, "--escrow-recipient", "age1invalid")
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: []string{""}}
+			tui := tui.New(tty, out)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client), tpmtestutils.WithRoot(root))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				is.Equal(len(c.Requests), 0) // No key is created when it can't be escrowed
+				return
+			}
+
+			is.True(logs.Len() == 0) // No logs printed by default
+
+			files, err := filepath.Glob(filepath.Join(escrowDir, "*-test-*.age"))
+			is.NoErr(err)
+			is.Equal(len(files), 1)                                                       // Recovery key is escrowed in a single file
+			is.True(strings.Contains(out.String(), "Recovery key escrowed to "+files[0])) // Escrow file is reported
+
+			for _, id := range ids {
+				f, err := os.Open(files[0])
+				is.NoErr(err) // Setup: could not open escrow file
+				defer f.Close()
+
+				record, err := escrow.Open(f, strings.NewReader(id.String()))
+				is.NoErr(err) // Each recipient can open the escrowed recovery key
+
+				hostname, err := os.Hostname()
+				is.NoErr(err) // Setup: could not get hostname
+
+				is.Equal(record.KeyID, "test")                                                  // Record holds the key ID
+				is.Equal(record.RecoveryKey, "11272-47509-28031-54818-41671-38673-11053-06376") // Record holds the recovery key
+				is.Equal(record.ContainerRoles, []string{"system-data", "system-save"})         // Record holds the container roles
+				is.Equal(record.Hostname, hostname)                                             // Record holds the hostname
+				is.Equal(record.MachineID, machineID)                                           // Record holds the machine ID
+			}
+		})
+	}
+}
+
+func TestEscrowDecrypt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		file     string
+		identity string
+		format   string
+
+		wantErr bool
+	}{
+		"Success_decrypting_escrow_file":         {},
+		"Success_decrypting_escrow_file_in_json": {format: "json"},
+		"Success_decrypting_escrow_file_in_yaml": {format: "yaml"},
+
+		"Error_without_escrow_file":              {file: "-", wantErr: true},
+		"Error_on_missing_escrow_file":           {file: "missing.age", wantErr: true},
+		"Error_on_missing_identity":              {identity: "missing", wantErr: true},
+		"Error_on_identity_of_another_recipient": {identity: "other-identity", wantErr: true},
+		"Error_on_corrupted_escrow_file":         {file: "corrupted.age", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			dir := testutils.TestFamilyPath(t)
+			if tc.file == "" {
+				tc.file = "my-host-my-key-20261017T093000Z.age"
+			}
+			if tc.identity == "" {
+				tc.identity = "identity"
+			}
+
+			args := []string{}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
+			args = append(args, "escrow", "decrypt", "--identity", filepath.Join(dir, tc.identity))
+			if tc.file != "-" {
+				args = append(args, filepath.Join(dir, tc.file))
+			}
+
+			var out strings.Builder
+			app := cmd.New(
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(nil, &out)),
+			)
+
+			err := app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			golden.CheckOrUpdate(t, out.String()) // TestEscrowDecrypt returns the expected output
+		})
+	}
+}
+
+func TestEscrowServer(t *testing.T) {
+	t.Parallel()
+
+	const machineID = "0123456789abcdef0123456789abcdef"
+
+	tests := map[string]struct {
+		command     string
+		withDir     bool
+		noRecipient bool
+		unreachable bool
+		failing     bool
+		limited     bool
+		rejected    bool
+		queueIsFile bool
+		noCert      bool
+		noServer    bool
+		httpServer  bool
+		noMachineID bool
+
+		wantQueued     bool
+		wantKeyCreated bool
+		wantErr        bool
+	}{
+		"Success_escrowing_created_recovery_key":                {},
+		"Success_escrowing_regenerated_recovery_key":            {command: "regenerate-recovery-key"},
+		"Success_escrowing_to_server_and_directory":             {withDir: true},
+		"Success_queueing_recovery_key_when_server_unreachable": {unreachable: true, wantQueued: true},
+		"Success_queueing_recovery_key_when_server_fails":       {failing: true, wantQueued: true},
+		"Success_queueing_recovery_key_when_server_limits":      {limited: true, wantQueued: true},
+
+		"Error_without_escrow_cert":                 {noCert: true, wantErr: true},
+		"Error_on_escrow_cert_without_server":       {noServer: true, wantErr: true},
+		"Error_on_http_escrow_server":               {httpServer: true, wantErr: true},
+		"Error_on_escrow_server_without_recipient":  {noRecipient: true, wantErr: true},
+		"Error_when_machine_id_is_unreadable":       {noMachineID: true, wantErr: true},
+		"Error_when_server_rejects_recovery_key":    {rejected: true, wantKeyCreated: true, wantErr: true},
+		"Error_when_recovery_key_can_not_be_queued": {unreachable: true, queueIsFile: true, wantKeyCreated: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			if tc.command == "" {
+				tc.command = "create-recovery-key"
+			}
+
+			root := t.TempDir()
+			if !tc.noMachineID {
+				err := os.MkdirAll(filepath.Join(root, "etc"), 0o750)
+				is.NoErr(err) // Setup: could not create etc directory
+				err = os.WriteFile(filepath.Join(root, "etc", "machine-id"), []byte(machineID+"\n"), 0o600)
+				is.NoErr(err) // Setup: could not write machine-id
+			}
+
+			var serverOpts []escrowtestutils.Option
+			if tc.rejected {
+				serverOpts = append(serverOpts, escrowtestutils.WithFailures(1, http.StatusForbidden))
+			}
+			if tc.failing {
+				serverOpts = append(serverOpts, escrowtestutils.WithFailures(3, http.StatusServiceUnavailable))
+			}
+			if tc.limited {
+				serverOpts = append(serverOpts, escrowtestutils.WithFailures(3, http.StatusTooManyRequests))
+			}
+			server := escrowtestutils.NewMockEscrowServer(t, ctx, serverOpts...)
+			if tc.unreachable {
+				server.Close()
+			}
+
+			queueDir := filepath.Join(t.TempDir(), "queue")
+			if tc.queueIsFile {
+				err := os.WriteFile(queueDir, nil, 0o600)
+				is.NoErr(err) // Setup: could not create file
+			}
+
+			args := []string{tc.command, "test", "--verify=false", "--escrow-key", server.KeyFile, "--escrow-ca", server.CAFile}
+			switch {
+			case tc.httpServer:
+				args = append(args, "--escrow-server", strings.Replace(server.URL, "https://", "http://", 1))
+			case !tc.noServer:
+				args = append(args, "--escrow-server", server.URL)
+			}
+			if !tc.noCert {
+				args = append(args, "--escrow-cert", server.CertFile)
+			}
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+
+			escrowDir := t.TempDir()
+			if tc.withDir {
+				args = append(args, "--escrow-dir", escrowDir)
+			}
+			if !tc.noRecipient {
+				args = append(args, "--escrow-recipient", id.Recipient().String())
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: []string{""}}
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client), tpmtestutils.WithRoot(root))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+				cmdtestutils.WithEscrowQueueDir(queueDir),
+				cmdtestutils.WithEscrowClientOptions(escrowtestutils.WithRetryDelay(time.Millisecond)),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				if !tc.wantKeyCreated {
+					is.Equal(len(c.Requests), 0) // No key is created when it can't be escrowed
+					return
+				}
+				is.True(strings.Contains(out.String(), "Recovery Key: ")) // Created recovery key is still shown
+
+				if !tc.queueIsFile {
+					queued, err := escrow.NewQueue(queueDir).Pending()
+					is.NoErr(err)            // Queue can be read
+					is.Equal(len(queued), 0) // Recovery key is not queued
+				}
+				return
+			}
+
+			queued, err := escrow.NewQueue(queueDir).Pending()
+			is.NoErr(err) // Queue can be read
+
+			var record escrow.Record
+			if tc.wantQueued {
+				is.Equal(len(queued), 1)                                        // Recovery key is queued
+				is.Equal(len(server.Records()), 0)                              // Server did not record the recovery key
+				is.True(strings.Contains(out.String(), "queued to "+queued[0])) // Queued record is reported
+				is.True(logs.Len() > 0)                                         // Escrow failure is logged
+
+				data, err := os.ReadFile(queued[0])
+				is.NoErr(err)                                                                               // Queued record can be read
+				is.True(!strings.Contains(string(data), "11272-47509-28031-54818-41671-38673-11053-06376")) // Recovery key is not in plaintext
+
+				record, err = escrow.Open(strings.NewReader(string(data)), strings.NewReader(id.String()))
+				is.NoErr(err) // Queued record can be opened by the escrow recipient
+			} else {
+				is.Equal(len(queued), 0)                                                        // Nothing is queued
+				is.Equal(len(server.Records()), 1)                                              // Server recorded the recovery key
+				is.True(strings.Contains(out.String(), "Recovery key escrowed to "+server.URL)) // Server escrow is reported
+				is.True(logs.Len() == 0)                                                        // No logs printed by default
+
+				record = server.Records()[0]
+			}
+
+			hostname, err := os.Hostname()
+			is.NoErr(err) // Setup: could not get hostname
+
+			is.Equal(record.KeyID, "test")                                                  // Record holds the key ID
+			is.Equal(record.RecoveryKey, "11272-47509-28031-54818-41671-38673-11053-06376") // Record holds the recovery key
+			is.Equal(record.ContainerRoles, []string{"system-data", "system-save"})         // Record holds the container roles
+			is.Equal(record.Hostname, hostname)                                             // Record holds the hostname
+			is.Equal(record.MachineID, machineID)                                           // Record holds the machine ID
+
+			if tc.withDir {
+				files, err := filepath.Glob(filepath.Join(escrowDir, "*-test-*.age"))
+				is.NoErr(err)
+				is.Equal(len(files), 1) // Recovery key is also escrowed in the directory
+			}
+		})
+	}
+}
+
+func TestEscrowSync(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		queued        int
+		unreachable   bool
+		rejected      int
+		noServer      bool
+		noCert        bool
+		nonRoot       bool
+		dryRun        bool
+		noIdentity    bool
+		otherIdentity bool
+
+		wantSent   int
+		wantStatus int
+		wantErr    bool
+	}{
+		"Success_sending_queued_recovery_keys":            {queued: 2, wantSent: 2},
+		"Success_without_queued_recovery_key":             {},
+		"Success_showing_queued_recovery_keys_in_dry_run": {queued: 2, dryRun: true, wantStatus: 2},
+
+		"Error_when_server_is_unreachable":       {queued: 2, unreachable: true, wantErr: true},
+		"Error_when_server_rejects_recovery_key": {queued: 2, rejected: 1, wantSent: 1, wantErr: true},
+		"Error_without_escrow_server":            {queued: 1, noServer: true, wantErr: true},
+		"Error_without_escrow_cert":              {queued: 1, noCert: true, wantErr: true},
+		"Error_when_not_root":                    {queued: 1, nonRoot: true, wantErr: true},
+		"Error_without_identity":                 {queued: 1, noIdentity: true, wantErr: true},
+		"Error_on_identity_of_another_recipient": {queued: 1, otherIdentity: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			var serverOpts []escrowtestutils.Option
+			if tc.rejected > 0 {
+				serverOpts = append(serverOpts, escrowtestutils.WithFailures(tc.rejected, http.StatusForbidden))
+			}
+			server := escrowtestutils.NewMockEscrowServer(t, ctx, serverOpts...)
+			if tc.unreachable {
+				server.Close()
+			}
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+
+			q := escrow.NewQueue(filepath.Join(t.TempDir(), "queue"))
+			for i := range tc.queued {
+				_, err := q.Add(escrow.Record{
+					KeyID:       fmt.Sprintf("key-%d", i),
+					RecoveryKey: "11272-47509-28031-54818-41671-38673-11053-06376",
+					Hostname:    "my-host",
+					Date:        time.Date(2026, 10, 17, 9, 30, i, 0, time.UTC),
+				}, []age.Recipient{id.Recipient()})
+				is.NoErr(err) // Setup: could not queue record
+			}
+
+			identity := id
+			if tc.otherIdentity {
+				identity, err = age.GenerateX25519Identity()
+				is.NoErr(err) // Setup: could not generate identity
+			}
+			identityFile := filepath.Join(t.TempDir(), "identity")
+			err = os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0o600)
+			is.NoErr(err) // Setup: could not write identity
+
+			args := []string{"escrow", "sync", "--escrow-key", server.KeyFile, "--escrow-ca", server.CAFile}
+			if !tc.noIdentity {
+				args = append(args, "--identity", identityFile)
+			}
+			if !tc.noServer {
+				args = append(args, "--escrow-server", server.URL)
+			}
+			if !tc.noCert {
+				args = append(args, "--escrow-cert", server.CertFile)
+			}
+			if tc.dryRun {
+				args = append([]string{"--dry-run"}, args...)
+			}
+
+			euid := 0
+			if tc.nonRoot {
+				euid = 1000
+			}
+
+			var out strings.Builder
+			app := cmd.New(
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithEuid(euid),
+				cmdtestutils.WithTui(tui.New(nil, &out)),
+				cmdtestutils.WithEscrowQueueDir(q.Dir()),
+				cmdtestutils.WithEscrowClientOptions(escrowtestutils.WithRetryDelay(time.Millisecond)),
+			)
+
+			err = app.Run(ctx)
+
+			pending, e := q.Pending()
+			is.NoErr(e)                                   // Queue can be read
+			is.Equal(len(pending), tc.queued-tc.wantSent) // Recovery keys which were not sent are still queued
+			if !tc.unreachable {
+				is.Equal(len(server.Records()), tc.wantSent) // Server recorded the sent recovery keys
+			}
+
+			// Changes which would be made in dry-run mode are reported with the exit status, which is not an error.
+			var status cmd.ExitStatusError
+			if errors.As(err, &status) {
+				err = nil
+			}
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(int(status), tc.wantStatus) // Exit status reports whether recovery keys would be sent
+
+			golden.CheckOrUpdate(t, strings.ReplaceAll(out.String(), server.URL, "<server>")) // TestEscrowSync returns the expected output
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/secretgen"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// defaultPassphraseWords is the number of words of generated passphrases, for 66 bits of entropy.
+	defaultPassphraseWords = 6
+	// defaultPINLength is the number of digits of generated PINs.
+	defaultPINLength = 8
+)
+
+// generateFlags are the flags generating the new secret of the given kind instead of reading it,
+// with strength setting how strong it is.
+func generateFlags(kind string, strength cli.Flag) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "generate",
+			Usage: fmt.Sprintf("Generate a strong %s instead of asking for one. It is shown once, before it is used", kind),
+		},
+		strength,
+		&cli.BoolFlag{
+			Name:  "verify",
+			Usage: fmt.Sprintf("Re-enter the generated %s to make sure it was recorded. Disable with --verify=false", kind),
+			Value: true,
+		},
+	}
+}
+
+// newPassphrase returns the new passphrase, generated or read as requested on the command line.
+func (a App) newPassphrase(ctx context.Context, cmd *cli.Command) (string, error) {
+	if !cmd.Bool("generate") {
+		return a.readNewSecret(ctx, "passphrase")
+	}
+
+	return a.generateSecret(ctx, cmd, "passphrase", func() (string, error) {
+		return secretgen.Passphrase(a.rand, cmd.Int("words"))
+	})
+}
+
+// newPIN returns the new PIN, generated or read as requested on the command line.
+func (a App) newPIN(ctx context.Context, cmd *cli.Command) (string, error) {
+	if !cmd.Bool("generate") {
+		return a.readNewSecret(ctx, "PIN")
+	}
+
+	return a.generateSecret(ctx, cmd, "PIN", func() (string, error) {
+		return secretgen.PIN(a.rand, cmd.Int("length"))
+	})
+}
+
+// generateSecret generates a new secret of the given kind passing the quality checks, and hands it over
+// before it is used, so that nothing is changed if it was not recorded.
+func (a App) generateSecret(ctx context.Context, cmd *cli.Command, kind string, generate func() (string, error)) (string, error) {
+	check := a.secretCheck(kind)
+	for attempt := 1; ; attempt++ {
+		secret, err := generate()
+		if err != nil {
+			return "", err
+		}
+
+		_, err = check(ctx, secret)
+		if _, _, refused := qualityRefusal(err); refused && attempt < maxSecretAttempts {
+			log.Info(ctx, "Generated %s refused by the quality checks, generating another one", kind)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// Nothing is changed: there is nothing to record.
+		if isDryRun(cmd) {
+			return secret, nil
+		}
+
+		return secret, a.handOverSecret(cmd, secretHandOver{
+			kind:   kind,
+			label:  upperFirst(kind),
+			secret: secret,
+			want:   secret,
+			read: func() (string, error) {
+				return a.tui.ReadUserSecret(fmt.Sprintf("Enter %s: ", kind))
+			},
+
+			ifCancelled: "nothing was changed",
+			ifMismatch:  "nothing was changed",
+		})
+	}
+}
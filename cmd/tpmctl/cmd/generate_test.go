@@ -0,0 +1,156 @@
+package cmd_test
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/secretgen"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+// secretPlaceholder is replaced in the answers by the secret generated for the test.
+const secretPlaceholder = "<secret>"
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		command string
+		// length is the number of digits or words of the generated secret, if not the default one.
+		length  int
+		args    []string
+		answers []string
+		// refused is the number of generated secrets refused by the quality checks before one is accepted.
+		refused int
+
+		wantNoChange bool
+		wantErr      bool
+	}{
+		"Success_generating_PIN":                        {answers: []string{"", secretPlaceholder}},
+		"Success_generating_PIN_of_given_length":        {length: 12, answers: []string{"", secretPlaceholder}},
+		"Success_generating_passphrase":                 {command: "add-passphrase", answers: []string{"", secretPlaceholder}},
+		"Success_generating_passphrase_of_given_length": {command: "add-passphrase", length: 8, answers: []string{"", secretPlaceholder}},
+		"Success_after_a_mismatch":                      {answers: []string{"", "1234", "", secretPlaceholder}},
+		"Success_after_refused_PIN":                     {refused: 1, answers: []string{"", secretPlaceholder}},
+		"Success_without_verification":                  {args: []string{"--verify=false"}, answers: []string{""}},
+		"Success_in_dry_run":                            {args: []string{"--dry-run"}, wantNoChange: true},
+
+		"Error_after_too_many_mismatches":      {answers: []string{"", "1234", "n", "2345", "n", "3456"}, wantNoChange: true, wantErr: true},
+		"Error_when_verification_is_cancelled": {answers: []string{"", "\x03"}, wantNoChange: true, wantErr: true},
+		"Error_when_all_PINs_are_refused":      {wantNoChange: true, wantErr: true},
+		"Error_on_invalid_PIN_length":          {args: []string{"--length", "0"}, wantNoChange: true, wantErr: true},
+		"Error_on_invalid_number_of_words":     {command: "add-passphrase", args: []string{"--words", "13"}, wantNoChange: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			if tc.command == "" {
+				tc.command = "add-pin"
+			}
+			args := append([]string{tc.command, "--generate"}, tc.args...)
+			if tc.length != 0 {
+				args = append(args, lengthFlag(tc.command), fmt.Sprint(tc.length))
+			}
+
+			// Generate the same secrets as the command, to type the accepted one when asked to.
+			var seed [32]byte
+			copy(seed[:], name)
+			secret := generatedSecret(is, tc.command, tc.length, seed, tc.refused)
+			for i, a := range tc.answers {
+				tc.answers[i] = strings.ReplaceAll(a, secretPlaceholder, secret)
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+				cmdtestutils.WithEuid(0),
+				cmdtestutils.WithRand(mathrand.NewChaCha8(seed)),
+			)
+
+			err = app.Run(ctx)
+
+			// Changes which would be made are reported with the exit status, which is not an error.
+			if _, ok := errors.AsType[cmd.ExitStatusError](err); ok {
+				err = nil
+			}
+
+			// Secrets are only checked until they are recorded.
+			if tc.wantNoChange {
+				for _, r := range c.Requests {
+					is.True(r.Method == "GET" || strings.Contains(r.Body, `"action":"check-`)) // Request does not change the system
+				}
+			}
+
+			got := out.String()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				got = fmt.Sprintf("%s\nError: %s", got, err)
+				golden.CheckOrUpdate(t, got) // TestGenerate fails with the expected output
+				return
+			}
+
+			if !tc.wantNoChange {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, `"action":"replace-platform-key"`, secret)
+			}
+
+			golden.CheckOrUpdate(t, got) // TestGenerate returns the expected output
+		})
+	}
+}
+
+// lengthFlag returns the flag setting the length of the secret generated by command.
+func lengthFlag(command string) string {
+	if command == "add-passphrase" {
+		return "--words"
+	}
+	return "--length"
+}
+
+// generatedSecret returns the secret of the given length the command generates from seed,
+// once the refused ones are skipped.
+func generatedSecret(is *is.I, command string, length int, seed [32]byte, refused int) string {
+	is.Helper()
+
+	generate := func(r io.Reader) (string, error) { return secretgen.PIN(r, cmp.Or(length, 8)) }
+	if command == "add-passphrase" {
+		generate = func(r io.Reader) (string, error) { return secretgen.Passphrase(r, cmp.Or(length, 6)) }
+	}
+
+	r := mathrand.NewChaCha8(seed)
+	var secret string
+	for range refused + 1 {
+		var err error
+		secret, err = generate(r)
+		is.NoErr(err) // Setup: could not generate secret
+	}
+
+	return secret
+}
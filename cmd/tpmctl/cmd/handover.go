@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// maxVerifyAttempts is how many times a new secret can be re-entered before giving up on its verification.
+const maxVerifyAttempts = 3
+
+// secretHandOver describes a new secret which is shown once, and re-entered to make sure it was recorded.
+type secretHandOver struct {
+	// kind names the secret in messages, like "recovery key", and label introduces it when it is shown.
+	kind  string
+	label string
+
+	secret string
+	// want is the secret as returned by read.
+	want string
+	qr   bool
+	read func() (string, error)
+
+	// ifCancelled and ifMismatch tell what to do when the secret could not be verified.
+	ifCancelled string
+	ifMismatch  string
+}
+
+// handOverSecret prints the secret and asks to re-enter it when enabled.
+func (a App) handOverSecret(cmd *cli.Command, h secretHandOver) error {
+	// Nobody is there to confirm: keep the secret printed.
+	if !a.tui.Interactive() {
+		fmt.Fprintf(a.tui.Writer(), "%s: %s\n", h.label, h.secret)
+		if h.qr {
+			_, err := a.tui.DisplayQRCode(h.secret)
+			return err
+		}
+		return nil
+	}
+
+	if err := a.showSecret(h); err != nil {
+		return err
+	}
+
+	// Typing the secret with masked echo requires a terminal.
+	if !cmd.Bool("verify") || !a.tui.IsTerminal() {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		fmt.Fprintf(a.tui.Writer(), "Re-enter the %s to confirm it was recorded.\n", h.kind)
+
+		secret, err := h.read()
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			return fmt.Errorf("%s verification cancelled: %s", h.kind, h.ifCancelled)
+		}
+
+		if secret == h.want {
+			fmt.Fprintf(a.tui.Writer(), "%s verified\n", upperFirst(h.kind))
+			return nil
+		}
+
+		if attempt == maxVerifyAttempts {
+			return fmt.Errorf("%s does not match after %d attempts: %s", h.kind, maxVerifyAttempts, h.ifMismatch)
+		}
+
+		left := fmt.Sprintf("%d attempts", maxVerifyAttempts-attempt)
+		if maxVerifyAttempts-attempt == 1 {
+			left = "1 attempt"
+		}
+		fmt.Fprintf(a.tui.Writer(), "%s does not match (%s left)\n", upperFirst(h.kind), left)
+		show, err := a.tui.Confirm(fmt.Sprintf("Show the %s again?", h.kind), false)
+		if err != nil {
+			return err
+		}
+		if show {
+			if err := a.showSecret(h); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// showSecret prints the secret, and its QR code if requested, until Enter is pressed,
+// then erases it from the screen.
+func (a App) showSecret(h secretHandOver) error {
+	fmt.Fprintf(a.tui.Writer(), "%s: %s\n", h.label, h.secret)
+	lines := 2
+
+	if h.qr {
+		n, err := a.tui.DisplayQRCode(h.secret)
+		if err != nil {
+			return err
+		}
+		lines += n
+	}
+
+	// Wait for user to confirm by pressing Enter
+	fmt.Fprintf(a.tui.Writer(), "Save the %s somewhere safe. Press Enter to continue...", h.kind)
+	_ = a.tui.WaitForEnter()
+	a.tui.ClearPreviousLines(lines)
+
+	return nil
+}
+
+// upperFirst returns s with its first letter in upper case, like at the start of a sentence.
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,97 @@
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestInterrupt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		onInterrupt string
+		answer      string
+
+		wantErr    error
+		wantErrMsg string
+	}{
+		"Aborts_change_by_default":        {wantErr: snapd.ErrChangeAborted},
+		"Detaches_from_change_on_request": {onInterrupt: "detach", wantErr: snapd.ErrChangeDetached},
+		"Asks_and_aborts_change":          {onInterrupt: "ask", answer: "y", wantErr: snapd.ErrChangeAborted},
+		"Asks_and_detaches_from_change":   {onInterrupt: "ask", answer: "n", wantErr: snapd.ErrChangeDetached},
+
+		"Error_on_unsupported_interrupt_action": {
+			onInterrupt: "ignore",
+			wantErrMsg:  `invalid value "ignore" for flag -on-interrupt: unsupported interrupt action "ignore", must be one of [abort detach ask]`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			var out strings.Builder
+			tui := tui.New(tty, &out)
+
+			if tc.answer != "" {
+				go fmt.Fprintln(ptmx, tc.answer)
+			}
+
+			args := []string{"remove-pin"}
+			if tc.onInterrupt != "" {
+				args = append([]string{"--on-interrupt", tc.onInterrupt}, args...)
+			}
+
+			// Simulate an interruption once the change is running, when its status is first requested.
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			c := snapdtestutils.NewMockSnapdServer(t, ctx, snapdtestutils.WithOnRequest(func(method, path string) {
+				if method == http.MethodGet && strings.HasPrefix(path, "/v2/changes/") {
+					cancel()
+				}
+			}))
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui),
+				cmdtestutils.WithEuid(0),
+			)
+
+			err = app.Run(ctx)
+			is.True(err != nil) // Run returns an error when interrupted
+			if tc.wantErrMsg != "" {
+				is.Equal(err.Error(), tc.wantErrMsg) // Run returns the validation error
+				is.Equal(len(c.Requests), 0)         // No request is sent to snapd
+				return
+			}
+			is.True(errors.Is(err, tc.wantErr)) // Run returns the expected interruption error
+
+			golden.CheckOrUpdate(t, out.String()) // TestInterrupt returns the correct output
+		})
+	}
+}
@@ -31,6 +31,10 @@ func (a App) newListAllCmd() *cli.Command {
 				return err
 			}
 
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, newKeyslotsOutput(result, nil))
+			}
+
 			if err := displayAllKeys(a.tui, result, hideHeaders); err != nil {
 				return err
 			}
@@ -51,6 +55,10 @@ func (a App) newListPassphraseCmd() *cli.Command {
 				return err
 			}
 
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, newKeyslotsOutput(result, snapd.IsPassphrase))
+			}
+
 			data := parseKeySlots(result, snapd.IsPassphrase)
 
 			displayKeySlotsFromMap(a.tui, "Passphrases", data)
@@ -71,6 +79,10 @@ func (a App) newListRecoveryKeyCmd() *cli.Command {
 				return err
 			}
 
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, newKeyslotsOutput(result, snapd.IsRecoveryKey))
+			}
+
 			data := parseKeySlots(result, snapd.IsRecoveryKey)
 
 			displayKeySlotsFromMap(a.tui, "Recovery Keys", data)
@@ -91,6 +103,10 @@ func (a App) newListPINCmd() *cli.Command {
 				return err
 			}
 
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, newKeyslotsOutput(result, snapd.IsPIN))
+			}
+
 			data := parseKeySlots(result, snapd.IsPIN)
 
 			displayKeySlotsFromMap(a.tui, "PINs", data)
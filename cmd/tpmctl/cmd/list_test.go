@@ -23,12 +23,15 @@ func TestListAll(t *testing.T) {
 
 	tests := map[string]struct {
 		hideHeaders   bool
+		format        string
 		tuiWriteError bool
 
 		wantErr bool
 	}{
 		"Success_on_getting_keyslots":                 {},
 		"Success_on_getting_keyslots_without_headers": {hideHeaders: true},
+		"Success_on_getting_keyslots_as_json":         {format: "json"},
+		"Success_on_getting_keyslots_as_yaml":         {format: "yaml"},
 
 		"Error_on_getting_keyslots":    {wantErr: true},
 		"Error_on_displaying_keyslots": {tuiWriteError: true, wantErr: true},
@@ -47,6 +50,9 @@ func TestListAll(t *testing.T) {
 			if tc.hideHeaders {
 				args = append(args, "--no-headers")
 			}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
 
 			var out strings.Builder
 			w := testWriter{io.Writer(&out), tc.tuiWriteError}
@@ -82,9 +88,13 @@ func TestListFiltered(t *testing.T) {
 	}
 
 	tests := map[string]struct {
+		format string
+
 		wantErr bool
 	}{
-		"Success_on_getting_keyslots": {},
+		"Success_on_getting_keyslots":         {},
+		"Success_on_getting_keyslots_as_json": {format: "json"},
+		"Success_on_getting_keyslots_as_yaml": {format: "yaml"},
 
 		"Error_on_getting_keyslots": {wantErr: true},
 	}
@@ -97,6 +107,11 @@ func TestListFiltered(t *testing.T) {
 				is := is.New(t)
 				ctx, logs := testutils.TestLoggerWithBuffer(t)
 
+				args := []string{command}
+				if tc.format != "" {
+					args = append(args, "--format", tc.format)
+				}
+
 				var out strings.Builder
 				w := io.Writer(&out)
 				tui := tui.New(nil, w)
@@ -105,7 +120,7 @@ func TestListFiltered(t *testing.T) {
 				s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 				app := cmd.New(
 					cmdtestutils.WithSnapTPM(s),
-					cmdtestutils.WithArgs(command),
+					cmdtestutils.WithArgs(args...),
 					cmdtestutils.WithTui(tui),
 				)
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/canonical/snap-tpmctl/internal/tui"
@@ -12,25 +13,97 @@ import (
 	"github.com/urfave/cli/v3"
 )
 
+// authRequestor asks for the credentials unlocking volumes, and reports the result of each attempt.
 type authRequestor struct {
 	tui.Tui
+
+	// failures are how many times each type of credential was refused.
+	failures map[secboot.UserAuthType]int
+}
+
+// authTypeNames are the names of the types of credential, in the order they are offered.
+var authTypeNames = []struct {
+	authType secboot.UserAuthType
+	name     string
+}{
+	{secboot.UserAuthTypePassphrase, "passphrase"},
+	{secboot.UserAuthTypePIN, "PIN"},
+	{secboot.UserAuthTypeRecoveryKey, "recovery key"},
+}
+
+// describeAuthTypes returns a human readable list of the types of credential in authTypes, like "passphrase or PIN".
+func describeAuthTypes(authTypes secboot.UserAuthType) string {
+	var names []string
+	for _, t := range authTypeNames {
+		if authTypes&t.authType != 0 {
+			names = append(names, t.name)
+		}
+	}
+
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
 }
 
 func (r *authRequestor) RequestUserCredential(ctx context.Context, name, path string, authTypes secboot.UserAuthType) (string, secboot.UserAuthType, error) {
-	if authTypes != secboot.UserAuthTypeRecoveryKey {
+	if authTypes == 0 {
 		return "", 0, secboot.ErrAuthRequestorNotAvailable
 	}
 
-	key, err := r.ReadRecoveryKey()
+	var cred string
+	var err error
+	if authTypes == secboot.UserAuthTypeRecoveryKey {
+		cred, err = r.ReadRecoveryKey()
+	} else {
+		cred, err = r.ReadUserSecret(fmt.Sprintf("Enter %s: ", describeAuthTypes(authTypes)))
+	}
 	if err != nil {
 		return "", 0, err
 	}
 
-	return key, authTypes, nil
+	return cred, authTypes, nil
 }
 
-// NotifyUserAuthResult isn't used in our implementation.
+// NotifyUserAuthResult reports whether the volume was unlocked, and how many tries are left otherwise.
 func (r *authRequestor) NotifyUserAuthResult(ctx context.Context, result secboot.UserAuthResult, authTypes, exhaustedAuthTypes secboot.UserAuthType) error {
+	what := describeAuthTypes(authTypes)
+
+	switch result {
+	case secboot.UserAuthResultSuccess:
+		fmt.Fprintf(r.Writer(), "Volume unlocked with %s\n", what)
+		return nil
+	case secboot.UserAuthResultInvalidFormat:
+		what = fmt.Sprintf("Invalid %s format", what)
+	default:
+		what = fmt.Sprintf("Wrong %s", what)
+	}
+
+	if r.failures == nil {
+		r.failures = make(map[secboot.UserAuthType]int)
+	}
+
+	// Any type of credential which was refused fewer times can still unlock the volume.
+	var left int
+	for _, t := range authTypeNames {
+		if authTypes&t.authType == 0 {
+			continue
+		}
+		r.failures[t.authType]++
+		if exhaustedAuthTypes&t.authType == 0 {
+			left = max(left, tpm.MaxUnlockTries-r.failures[t.authType])
+		}
+	}
+
+	switch {
+	case left <= 0:
+		fmt.Fprintf(r.Writer(), "%s, no tries left\n", what)
+	case left == 1:
+		fmt.Fprintf(r.Writer(), "%s, 1 try left\n", what)
+	default:
+		fmt.Fprintf(r.Writer(), "%s, %d tries left\n", what, left)
+	}
+
 	return nil
 }
 
@@ -53,7 +126,31 @@ func (a App) newMountVolumeCmd() *cli.Command {
 				Destination: &dir,
 			},
 		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "type",
+				Usage: fmt.Sprintf("Filesystem type of the volume: %s. Detected by default", strings.Join(tpm.Filesystems, ", ")),
+			},
+			&cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Mount the volume read-only. Not allowed yet by the confinement of the snap",
+			},
+			&cli.StringFlag{
+				Name:  "auth",
+				Value: string(tpm.UnlockAuthRecovery),
+				Usage: fmt.Sprintf("Credential unlocking the volume: %s. "+
+					"All but recovery try the platform key first", joinUnlockAuths()),
+			},
+			&cli.StringSliceFlag{
+				Name:  "options",
+				Usage: fmt.Sprintf("Comma-separated mount options: %s", strings.Join(tpm.MountOptionNames(), ", ")),
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if err := checkDryRunSupported(cmd); err != nil {
+				return err
+			}
+
 			if err := devicePathExists(device); err != nil {
 				return err
 			}
@@ -63,7 +160,14 @@ func (a App) newMountVolumeCmd() *cli.Command {
 				return err
 			}
 
-			if err := a.tpm.Mount(ctx, device, p, &authRequestor{a.tui}); err != nil {
+			opts := tpm.MountOptions{
+				FSType:   cmd.String("type"),
+				ReadOnly: cmd.Bool("read-only"),
+				Options:  cmd.StringSlice("options"),
+				Auth:     tpm.UnlockAuth(cmd.String("auth")),
+			}
+
+			if err := a.tpm.Mount(ctx, device, p, opts, &authRequestor{Tui: a.tui}); err != nil {
 				return err
 			}
 
@@ -87,6 +191,10 @@ func (a App) newUnmountVolumeCmd() *cli.Command {
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if err := checkDryRunSupported(cmd); err != nil {
+				return err
+			}
+
 			p, err := ensurePathIsAbsolute(dir)
 			if err != nil {
 				return err
@@ -101,6 +209,87 @@ func (a App) newUnmountVolumeCmd() *cli.Command {
 	}
 }
 
+func (a App) newActivateVolumeCmd() *cli.Command {
+	var device string
+
+	return &cli.Command{
+		Name:  "activate-volume",
+		Usage: "Unlock a LUKS encrypted volume without mounting it",
+		Description: "Maps the decrypted block device under /dev/mapper and prints its path, " +
+			"to run tools like fsck, dd or LVM on it.",
+		Suggest: true,
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:        "device",
+				UsageText:   "<device>",
+				Destination: &device,
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of the decrypted device under /dev/mapper. Derived from the device path by default",
+			},
+			&cli.StringFlag{
+				Name:  "auth",
+				Value: string(tpm.UnlockAuthRecovery),
+				Usage: fmt.Sprintf("Credential unlocking the volume: %s. "+
+					"All but recovery try the platform key first", joinUnlockAuths()),
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if err := checkDryRunSupported(cmd); err != nil {
+				return err
+			}
+
+			if err := devicePathExists(device); err != nil {
+				return err
+			}
+
+			mapperPath, err := a.tpm.Activate(ctx, device, cmd.String("name"), tpm.UnlockAuth(cmd.String("auth")), &authRequestor{Tui: a.tui})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(a.tui.Writer(), mapperPath)
+
+			return nil
+		},
+	}
+}
+
+func (a App) newDeactivateVolumeCmd() *cli.Command {
+	var volume string
+
+	return &cli.Command{
+		Name:        "deactivate-volume",
+		Usage:       "Lock a LUKS encrypted volume which is not mounted",
+		Description: "Removes the decrypted block device, given by its name or path under /dev/mapper, or by its encrypted device.",
+		Suggest:     true,
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:        "volume",
+				UsageText:   "<name|device>",
+				Destination: &volume,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if err := checkDryRunSupported(cmd); err != nil {
+				return err
+			}
+
+			mapperPath, err := a.tpm.Deactivate(ctx, volume)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(a.tui.Writer(), "%s removed\n", mapperPath)
+
+			return nil
+		},
+	}
+}
+
 func (a App) newGetLuksKeyFromRecoveryKeyCmd() *cli.Command {
 	var hex, escaped bool
 
@@ -154,6 +343,15 @@ func (a App) newGetLuksKeyFromRecoveryKeyCmd() *cli.Command {
 	}
 }
 
+// joinUnlockAuths returns the credentials volumes can be unlocked with, separated by commas.
+func joinUnlockAuths() string {
+	names := make([]string, 0, len(tpm.UnlockAuths))
+	for _, a := range tpm.UnlockAuths {
+		names = append(names, string(a))
+	}
+	return strings.Join(names, ", ")
+}
+
 // devicePathExists validates that a device path exists in the system.
 func devicePathExists(p string) error {
 	if p == "" {
@@ -165,20 +363,20 @@ func devicePathExists(p string) error {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("device %q does not exist", p)
 		}
-		return fmt.Errorf("failed to check device %q: %v", p, err)
+		return fmt.Errorf("failed to check device %q: %w", p, err)
 	}
 
 	return nil
 }
 
-// ensurePathIsAbsolute resolves to an absolute path.
+// ensurePathIsAbsolute resolves to an absolute path, cleaned to be compared with the mounted ones.
 func ensurePathIsAbsolute(p string) (string, error) {
 	if p == "" {
 		return "", fmt.Errorf("directory path cannot be empty")
 	}
 
 	if filepath.IsAbs(p) {
-		return p, nil
+		return filepath.Clean(p), nil
 	}
 
 	// Relative path: resolve against current working directory
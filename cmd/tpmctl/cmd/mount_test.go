@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
@@ -22,6 +23,7 @@ func TestMountVolume(t *testing.T) {
 	tests := map[string]struct {
 		device string
 		dir    string
+		args   []string
 
 		recoveryKey       string
 		syscall           tpmtestutils.TestSyscall
@@ -32,9 +34,13 @@ func TestMountVolume(t *testing.T) {
 		deviceStatError   bool
 		alreadyMountedErr bool
 
-		wantErr bool
+		wantFSType string
+		wantFlags  uintptr
+		wantErr    bool
 	}{
-		"Success on mounting volume": {},
+		"Success on mounting volume":   {},
+		"Success_with_filesystem_type": {args: []string{"--type", "xfs"}, wantFSType: "xfs"},
+		"Success_with_mount_options":   {args: []string{"--options", "rw,relatime"}, wantFlags: syscall.MS_RELATIME},
 
 		"Error_when_authRequestor_fails":                      {ttyReadError: true, wantErr: true},
 		"Error_when_mount_fails":                              {syscall: tpmtestutils.TestSyscall{WantErr: true}, wantErr: true},
@@ -43,6 +49,10 @@ func TestMountVolume(t *testing.T) {
 		"Error_when_device_is_empty":                          {emptyDeviceError: true, wantErr: true},
 		"Error_when_dir_path_is_empty":                        {emptyDirError: true, wantErr: true},
 		"Error_when_device_is_already_in_use_by_another_tool": {deviceInUse: true, wantErr: true},
+		"Error_on_unsupported_filesystem_type":                {args: []string{"--type", "btrfs"}, wantErr: true},
+		"Error_on_unsupported_mount_option":                   {args: []string{"--options", "rw,sync"}, wantErr: true},
+		"Error_on_mount_options_not_allowed_by_the_snap":      {args: []string{"--options", "noexec"}, wantErr: true},
+		"Error_on_read_only_mount":                            {args: []string{"--read-only"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -64,9 +74,7 @@ func TestMountVolume(t *testing.T) {
 			tc.device = filepath.Join(root, tc.device) // Convert to an absolute path
 
 			if !tc.deviceStatError {
-				f, err := os.Create(tc.device)
-				is.NoErr(err) // Setup: device should exist before mounting
-				defer f.Close()
+				tpmtestutils.SetupFilesystem(is, tc.device, "ext4")
 			}
 
 			if tc.dir == "" {
@@ -83,7 +91,7 @@ func TestMountVolume(t *testing.T) {
 				mapper := tpmtestutils.LuksVolumeName(tc.device)
 				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", filepath.Join(root, "dev", "mapper", mapper), tc.dir)
 			}
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 			tpmtestutils.SetupSysClassBlock(is, root, tc.device, tc.deviceInUse)
 
 			ptmx, tty, err := pty.Open()
@@ -118,7 +126,7 @@ func TestMountVolume(t *testing.T) {
 			)
 			app := cmd.New(
 				cmdtestutils.WithSnapTPM(s),
-				cmdtestutils.WithArgs(command, tc.device, tc.dir),
+				cmdtestutils.WithArgs(append(append([]string{command}, tc.args...), tc.device, tc.dir)...),
 				cmdtestutils.WithTui(tui),
 			)
 
@@ -128,19 +136,101 @@ func TestMountVolume(t *testing.T) {
 			}
 
 			is.True(logs.Len() == 0) // No logs printed by default
+
+			if tc.wantFSType == "" {
+				tc.wantFSType = "ext4"
+			}
+			if tc.wantFlags == 0 {
+				tc.wantFlags = syscall.MS_RELATIME
+			}
+			is.Equal(tc.syscall.FSType, tc.wantFSType) // the volume is mounted with the expected filesystem type
+			is.Equal(tc.syscall.Flags, tc.wantFlags)   // the volume is mounted with the expected flags
+		})
+	}
+}
+
+func TestMountVolumeAuth(t *testing.T) {
+	const credential = "test-passphrase"
+
+	tests := map[string]struct {
+		auth    string
+		answers []string
+
+		wantErr bool
+	}{
+		"Success_with_passphrase":              {auth: "passphrase", answers: []string{credential}},
+		"Success_with_PIN_after_wrong_attempt": {auth: "pin", answers: []string{"12345", credential}},
+		"Success_with_any_credential":          {auth: "auto", answers: []string{"wrong", credential}},
+
+		"Error_when_no_tries_are_left":        {auth: "passphrase", answers: []string{"wrong", "wrong", "wrong"}, wantErr: true},
+		"Error_on_unsupported_authentication": {auth: "fingerprint", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			t.Setenv("SNAP", root)
+
+			device := filepath.Join(root, "test-device")
+			tpmtestutils.SetupFilesystem(is, device, "ext4")
+			tpmtestutils.SetupMountInfo(is, root, "")
+			tpmtestutils.SetupSysClassBlock(is, root, device, false)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			var sc tpmtestutils.TestSyscall
+			activator := tpmtestutils.TestActivator{Credential: credential}
+			s := tpm.New(
+				tpmtestutils.WithRoot(root),
+				tpmtestutils.WithSyscall(&sc),
+				tpmtestutils.WithActivator(&activator),
+			)
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs("mount-volume", "--auth", tc.auth, device, filepath.Join(root, "mount-dir")),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+			)
+
+			err = app.Run(ctx)
+
+			got := out.String()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				// The device path changes with every run.
+				got = fmt.Sprintf("%s\nError: %s", got, strings.ReplaceAll(err.Error(), root, "<root>"))
+				golden.CheckOrUpdate(t, got) // TestMountVolumeAuth fails with the expected output
+				return
+			}
+
+			is.True(sc.Mounted)          // the volume is mounted once unlocked
+			golden.CheckOrUpdate(t, got) // TestMountVolumeAuth returns the expected output
 		})
 	}
 }
 
 func TestUnmountVolume(t *testing.T) {
 	tests := map[string]struct {
-		dir           string
+		dir string
+		// suffix is appended to the directory as given on the command line.
+		suffix        string
 		syscall       tpmtestutils.TestSyscall
 		emptyDirError bool
 
 		wantErr bool
 	}{
-		"Success on unmounting volume": {},
+		"Success on unmounting volume":  {},
+		"Success_with_trailing_slash":   {suffix: "/"},
+		"Success_with_repeated_slashes": {suffix: "//"},
 
 		"Error_when_unmount_fails":     {syscall: tpmtestutils.TestSyscall{WantErr: true}, wantErr: true},
 		"Error_when_dir_path_is_empty": {emptyDirError: true, wantErr: true},
@@ -168,19 +258,20 @@ func TestUnmountVolume(t *testing.T) {
 			tui := tui.New(nil, &out)
 
 			content := fmt.Sprintf("%s %s ext4 rw 0 0\n", filepath.Join(root, "dev", "mapper", "test"), tc.dir)
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 
 			if tc.emptyDirError {
 				tc.dir = ""
 			}
 
+			tc.syscall.Root = root
 			s := tpm.New(
 				tpmtestutils.WithRoot(root),
 				tpmtestutils.WithSyscall(&tc.syscall),
 			)
 			app := cmd.New(
 				cmdtestutils.WithSnapTPM(s),
-				cmdtestutils.WithArgs(command, tc.dir),
+				cmdtestutils.WithArgs(command, tc.dir+tc.suffix),
 				cmdtestutils.WithTui(tui),
 			)
 
@@ -189,7 +280,133 @@ func TestUnmountVolume(t *testing.T) {
 				return
 			}
 
+			is.True(tc.syscall.Unmounted) // the volume is unmounted
+			is.True(logs.Len() == 0)      // No logs printed by default
+		})
+	}
+}
+
+func TestActivateVolume(t *testing.T) {
+	tests := map[string]struct {
+		args    []string
+		answers []string
+
+		deviceMissing bool
+
+		wantMapper string
+		wantErr    bool
+	}{
+		"Success on activating volume":      {answers: []string{"11272-47509-28031-54818-41671-38673-11053-06376"}, wantMapper: "<device>"},
+		"Success with volume name":          {args: []string{"--name", "rescued"}, answers: []string{"11272-47509-28031-54818-41671-38673-11053-06376"}, wantMapper: "rescued"},
+		"Success_unlocking_with_passphrase": {args: []string{"--auth", "passphrase"}, answers: []string{"test-passphrase"}, wantMapper: "<device>"},
+
+		"Error_when_device_does_not_exist":    {deviceMissing: true, wantErr: true},
+		"Error_on_unsupported_authentication": {args: []string{"--auth", "fingerprint"}, wantErr: true},
+		"Error_when_no_tries_are_left":        {args: []string{"--auth", "pin"}, answers: []string{"1", "2", "3"}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			t.Setenv("SNAP", root)
+
+			device := filepath.Join(root, "test-device")
+			if !tc.deviceMissing {
+				tpmtestutils.SetupFilesystem(is, device, "ext4")
+			}
+			tpmtestutils.SetupSysClassBlock(is, root, device, false)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			s := tpm.New(
+				tpmtestutils.WithRoot(root),
+				tpmtestutils.WithActivator(&tpmtestutils.TestActivator{Credential: "test-passphrase"}),
+			)
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(append(append([]string{"activate-volume"}, tc.args...), device)...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
 			is.True(logs.Len() == 0) // No logs printed by default
+
+			if tc.wantMapper == "<device>" {
+				tc.wantMapper = tpmtestutils.LuksVolumeName(device)
+			}
+			want := filepath.Join(root, "dev", "mapper", tc.wantMapper)
+			is.True(strings.HasSuffix(out.String(), want+"\n")) // the path of the decrypted device is printed
+		})
+	}
+}
+
+func TestDeactivateVolume(t *testing.T) {
+	tests := map[string]struct {
+		volume  string
+		mounted bool
+
+		wantErr bool
+	}{
+		"Success on deactivating volume": {volume: "test-volume"},
+
+		"Error_when_volume_is_not_active": {volume: "other-volume", wantErr: true},
+		"Error_when_volume_is_mounted":    {volume: "test-volume", mounted: true, wantErr: true},
+		"Error_when_volume_is_empty":      {wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			t.Setenv("SNAP", root)
+
+			mapper := filepath.Join(root, "dev", "mapper", "test-volume")
+			err := os.MkdirAll(filepath.Dir(mapper), 0750)
+			is.NoErr(err) // Setup: mapper directory should exist
+			err = os.WriteFile(mapper, nil, 0600)
+			is.NoErr(err) // Setup: decrypted device should be mapped
+
+			content := ""
+			if tc.mounted {
+				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", mapper, filepath.Join(root, "mount-dir"))
+			}
+			tpmtestutils.SetupMountInfo(is, root, content)
+
+			var out strings.Builder
+			s := tpm.New(tpmtestutils.WithRoot(root))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs("deactivate-volume", tc.volume),
+				cmdtestutils.WithTui(tui.New(nil, &out)),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.True(logs.Len() == 0)                    // No logs printed by default
+			is.Equal(out.String(), mapper+" removed\n") // the removed mapper is printed
 		})
 	}
 }
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	"github.com/canonical/snap-tpmctl/internal/fdestate"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for the global --format flag.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// schemaVersion is the version of the structured output schema.
+// It must be bumped on any incompatible change of the types below.
+const schemaVersion = 1
+
+var outputFormats = []string{formatTable, formatJSON, formatYAML}
+
+// validateOutputFormat checks that the requested output format is supported.
+func validateOutputFormat(format string) error {
+	if !slices.Contains(outputFormats, format) {
+		return fmt.Errorf("unsupported output format %q, must be one of %v", format, outputFormats)
+	}
+	return nil
+}
+
+// outputFormat returns the output format requested on the command line.
+func outputFormat(cmd *cli.Command) string {
+	return cmd.String("format")
+}
+
+// statusOutput is the structured representation of the status command.
+type statusOutput struct {
+	SchemaVersion int    `json:"schema-version" yaml:"schema-version"`
+	Status        string `json:"status" yaml:"status"`
+}
+
+// versionOutput is the structured representation of the version command.
+type versionOutput struct {
+	SchemaVersion int    `json:"schema-version" yaml:"schema-version"`
+	Version       string `json:"version" yaml:"version"`
+}
+
+// keyslotsOutput is the structured representation of the list commands.
+type keyslotsOutput struct {
+	SchemaVersion int             `json:"schema-version" yaml:"schema-version"`
+	Keyslots      []keyslotOutput `json:"keyslots" yaml:"keyslots"`
+}
+
+// keyslotOutput describes a single keyslot of a container.
+type keyslotOutput struct {
+	ContainerRole string   `json:"container-role" yaml:"container-role"`
+	Volume        string   `json:"volume" yaml:"volume"`
+	VolumeName    string   `json:"volume-name" yaml:"volume-name"`
+	Encrypted     bool     `json:"encrypted" yaml:"encrypted"`
+	Name          string   `json:"name" yaml:"name"`
+	Type          string   `json:"type" yaml:"type"`
+	AuthMode      string   `json:"auth-mode" yaml:"auth-mode"`
+	PlatformName  string   `json:"platform-name" yaml:"platform-name"`
+	Roles         []string `json:"roles" yaml:"roles"`
+}
+
+// escrowRecordOutput is the structured representation of the escrow decrypt command.
+type escrowRecordOutput struct {
+	SchemaVersion int `json:"schema-version" yaml:"schema-version"`
+	escrow.Record `yaml:",inline"`
+}
+
+// planOutput is the structured representation of the apply --plan command.
+type planOutput struct {
+	SchemaVersion int `json:"schema-version" yaml:"schema-version"`
+	fdestate.Plan `yaml:",inline"`
+}
+
+// newKeyslotsOutput builds the structured output for all keyslots matching filter.
+// A nil filter selects every keyslot.
+func newKeyslotsOutput(data snapd.SystemVolumesResult, filter func(snapd.KeySlotInfo) bool) keyslotsOutput {
+	out := keyslotsOutput{
+		SchemaVersion: schemaVersion,
+		Keyslots:      []keyslotOutput{},
+	}
+
+	for _, k := range getAllKeys(data) {
+		if filter != nil && !filter(k.KeySlotInfo) {
+			continue
+		}
+
+		roles := k.Roles
+		if roles == nil {
+			roles = []string{}
+		}
+
+		out.Keyslots = append(out.Keyslots, keyslotOutput{
+			ContainerRole: k.containerRole,
+			Volume:        k.volume,
+			VolumeName:    k.volumeName,
+			Encrypted:     k.encrypted,
+			Name:          k.keySlotName,
+			Type:          string(k.Type),
+			AuthMode:      string(k.AuthMode),
+			PlatformName:  k.PlatformName,
+			Roles:         roles,
+		})
+	}
+
+	return out
+}
+
+// writeStructured serializes v to w in the given structured format.
+func writeStructured(w io.Writer, format string, v any) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	return fmt.Errorf("unsupported structured output format %q", format)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/urfave/cli/v3"
+)
+
+// Supported values for the global --on-interrupt flag.
+const (
+	interruptAbort  = "abort"
+	interruptDetach = "detach"
+	interruptAsk    = "ask"
+)
+
+var interruptActions = []string{interruptAbort, interruptDetach, interruptAsk}
+
+// validateInterruptAction checks that the requested action on interruption is supported.
+func validateInterruptAction(action string) error {
+	if !slices.Contains(interruptActions, action) {
+		return fmt.Errorf("unsupported interrupt action %q, must be one of %v", action, interruptActions)
+	}
+	return nil
+}
+
+// spinChange starts a spinner with msg listing the tasks of any snapd change run with the returned context.
+// If that context is cancelled, the change is aborted or left running as requested on the command line.
+func (a App) spinChange(ctx context.Context, cmd *cli.Command, msg string) (context.Context, func()) {
+	// No change is run in dry-run mode: there is nothing to wait for.
+	if isDryRun(cmd) {
+		return ctx, func() {}
+	}
+
+	update, stop := a.tui.SpinTasks(msg)
+
+	ctx = snapd.WithProgress(ctx, func(p snapd.ChangeProgress) {
+		tasks := make([]tui.Task, 0, len(p.Tasks))
+		for _, t := range p.Tasks {
+			tasks = append(tasks, tui.Task{Summary: t.Summary, Status: taskStatus(t)})
+		}
+		update(tasks)
+	})
+
+	ctx = snapd.WithInterrupt(ctx, func(changeID string) snapd.InterruptAction {
+		// Give the terminal back before asking anything.
+		stop()
+		return a.interruptAction(ctx, cmd.String("on-interrupt"))
+	})
+
+	return ctx, stop
+}
+
+// interruptAction returns what to do with a running change on interruption, asking the user if requested.
+func (a App) interruptAction(ctx context.Context, action string) snapd.InterruptAction {
+	switch action {
+	case interruptDetach:
+		return snapd.InterruptDetach
+	case interruptAsk:
+		abort, err := a.tui.Confirm("Interrupted. Abort the running operation? Otherwise it keeps running in the background.", true)
+		if err != nil {
+			log.Warn(ctx, "Aborting the running operation: %v", err)
+			return snapd.InterruptAbort
+		}
+		if !abort {
+			return snapd.InterruptDetach
+		}
+	}
+
+	return snapd.InterruptAbort
+}
+
+// taskStatus returns a human readable status of a snapd task.
+func taskStatus(t snapd.TaskProgress) string {
+	switch t.Status {
+	case "Do":
+		return "pending"
+	case "Doing":
+		if t.Total > 1 {
+			return fmt.Sprintf("running (%d/%d)", t.Done, t.Total)
+		}
+		return "running"
+	case "Done":
+		return "done"
+	case "Undo", "Undoing":
+		return "reverting"
+	case "Undone":
+		return "reverted"
+	case "Hold":
+		return "on hold"
+	case "Wait":
+		return "waiting"
+	case "Error":
+		return "failed"
+	}
+
+	return strings.ToLower(t.Status)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/canonical/snap-tpmctl/internal/recoverysheet"
+	"github.com/urfave/cli/v3"
+)
+
+// verifyRecoveryKeyFlag controls if a new recovery key must be re-entered to make sure it was recorded.
+func verifyRecoveryKeyFlag() *cli.BoolFlag {
+	return &cli.BoolFlag{
+		Name:  "verify",
+		Usage: "Re-enter the new recovery key to make sure it was recorded. Disable with --verify=false",
+		Value: true,
+	}
+}
+
+// recoveryKeyExportFlags are the flags exporting a new recovery key in other forms than text.
+func recoveryKeyExportFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "qr",
+			Usage: "Also display the recovery key as a QR code",
+		},
+		&cli.StringFlag{
+			Name:      "sheet",
+			Usage:     "Write a printable HTML sheet with the recovery key and recovery instructions to `FILE`",
+			TakesFile: true,
+		},
+	}, escrowFlags()...)
+}
+
+// checkRecoveryKeyExport validates the requested exports before any key is created, so that a new key
+// is not left without the export it was requested with.
+func (a App) checkRecoveryKeyExport(cmd *cli.Command) error {
+	if path := cmd.String("sheet"); path != "" {
+		if _, err := os.Lstat(path); err == nil {
+			return fmt.Errorf("recovery sheet %s already exists", path)
+		}
+	}
+
+	return a.checkEscrow(cmd)
+}
+
+// handOverRecoveryKey exports the new recovery key as requested, prints it and, when enabled, asks to
+// re-enter it before returning, so that we know it was recorded before it is erased from the screen.
+func (a App) handOverRecoveryKey(ctx context.Context, cmd *cli.Command, name, recoveryKey string, roles []string) error {
+	// The key is already in use: show it even if it could not be exported, so that it is not lost.
+	exportErr := a.exportRecoveryKey(ctx, cmd, name, recoveryKey, roles)
+
+	return errors.Join(exportErr, a.showAndVerifyRecoveryKey(cmd, name, recoveryKey))
+}
+
+// exportRecoveryKey writes the recovery sheet and escrows the recovery key of the container roles, if requested.
+func (a App) exportRecoveryKey(ctx context.Context, cmd *cli.Command, name, recoveryKey string, roles []string) error {
+	var errs []error
+
+	if path := cmd.String("sheet"); path != "" {
+		if err := writeRecoverySheet(path, name, recoveryKey, roles); err != nil {
+			errs = append(errs, err)
+		} else {
+			fmt.Fprintf(a.tui.Writer(), "Recovery sheet written to %s\n", path)
+		}
+	}
+
+	if err := a.escrowRecoveryKey(ctx, cmd, name, recoveryKey, roles); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// recoveryKeyContainerRoles returns the roles of the containers a new recovery key is added to.
+func recoveryKeyContainerRoles(cmd *cli.Command) []string {
+	if roles := containerRoles(cmd); len(roles) > 0 {
+		return roles
+	}
+
+	// Keys added without any container role are added to both of them by snapd.
+	return []string{"system-data", "system-save"}
+}
+
+// showAndVerifyRecoveryKey prints the recovery key and asks to re-enter it when enabled.
+func (a App) showAndVerifyRecoveryKey(cmd *cli.Command, name, recoveryKey string) error {
+	return a.handOverSecret(cmd, secretHandOver{
+		kind:   "recovery key",
+		label:  "Recovery Key",
+		secret: recoveryKey,
+		want:   strings.ReplaceAll(recoveryKey, "-", ""),
+		qr:     cmd.Bool("qr"),
+		read:   a.tui.ReadRecoveryKey,
+
+		ifCancelled: fmt.Sprintf("run 'regenerate-recovery-key %s' if it was not recorded", name),
+		ifMismatch:  fmt.Sprintf("run 'regenerate-recovery-key %s' to get a new one", name),
+	})
+}
+
+// writeRecoverySheet writes the printable recovery sheet to path, which must not exist yet.
+// The sheet holds a secret, so it is only readable by its owner.
+func writeRecoverySheet(path, name, recoveryKey string, roles []string) (err error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get hostname for recovery sheet: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create recovery sheet: %w", err)
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = fmt.Errorf("failed to close recovery sheet: %w", e)
+		}
+	}()
+
+	err = recoverysheet.Write(f, recoverysheet.Sheet{
+		RecoveryKey:    recoveryKey,
+		KeyID:          name,
+		Hostname:       hostname,
+		Date:           time.Now(),
+		ContainerRoles: roles,
+	})
+	if err != nil {
+		// Don't leave a partial sheet behind.
+		_ = os.Remove(path)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package cmd_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestRecoveryKeyVerification(t *testing.T) {
+	t.Parallel()
+
+	const key = "11272-47509-28031-54818-41671-38673-11053-06376"
+
+	tests := map[string]struct {
+		command     string
+		args        []string
+		answers     []string
+		sheet       bool
+		sheetExists bool
+
+		wantErr bool
+	}{
+		"Success_verifying_created_recovery_key":        {answers: []string{"", key}},
+		"Success_verifying_regenerated_recovery_key":    {command: "regenerate-recovery-key", answers: []string{"", key}},
+		"Success_verifying_recovery_key_without_dashes": {answers: []string{"", strings.ReplaceAll(key, "-", "")}},
+		"Success_after_a_mismatch":                      {answers: []string{"", "12345", "", key}},
+		"Success_showing_recovery_key_again":            {answers: []string{"", "12345", "y", "", key}},
+		"Success_without_verification":                  {args: []string{"--verify=false"}, answers: []string{""}},
+		"Success_displaying_qr_code":                    {args: []string{"--qr"}, answers: []string{"", key}},
+		"Success_writing_recovery_sheet":                {sheet: true, answers: []string{"", key}},
+
+		"Error_after_too_many_mismatches":      {answers: []string{"", "12345", "n", "23456", "n", "34567"}, wantErr: true},
+		"Error_when_verification_is_cancelled": {answers: []string{"", "\x03"}, wantErr: true},
+		"Error_reading_recovery_key":           {answers: []string{""}, wantErr: true},
+		"Error_when_recovery_sheet_exists":     {sheet: true, sheetExists: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			if tc.command == "" {
+				tc.command = "create-recovery-key"
+			}
+
+			dir := t.TempDir()
+			sheetPath := filepath.Join(dir, "sheet.html")
+			if tc.sheet {
+				tc.args = append(tc.args, "--sheet", sheetPath)
+			}
+			if tc.sheetExists {
+				err := os.WriteFile(sheetPath, []byte("existing"), 0o600)
+				is.NoErr(err) // Setup: could not create existing sheet
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+			tui := tui.New(tty, out)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(append([]string{tc.command, "test"}, tc.args...)...),
+				cmdtestutils.WithTui(tui),
+			)
+
+			err = app.Run(ctx)
+			got := strings.ReplaceAll(out.String(), dir, "<dir>")
+			if testutils.CheckError(is, err, tc.wantErr) {
+				got = fmt.Sprintf("%s\nError: %s", got, strings.ReplaceAll(err.Error(), dir, "<dir>"))
+				golden.CheckOrUpdate(t, got) // TestRecoveryKeyVerification fails with the expected output
+				return
+			}
+
+			is.True(logs.Len() == 0) // No logs printed by default
+
+			if tc.sheet {
+				fi, err := os.Stat(sheetPath)
+				is.NoErr(err)                                  // Recovery sheet is written
+				is.Equal(fi.Mode().Perm(), os.FileMode(0o600)) // Recovery sheet is only readable by its owner
+				sheet, err := os.ReadFile(sheetPath)
+				is.NoErr(err)                                 // Setup: could not read recovery sheet
+				is.True(strings.Contains(string(sheet), key)) // Recovery sheet holds the recovery key
+			}
+
+			golden.CheckOrUpdate(t, got) // TestRecoveryKeyVerification returns the expected output
+		})
+	}
+}
+
+// promptAnswerer records the output and types the next answer in the terminal each time a prompt is printed.
+// Answering only once the input is expected keeps the line discipline from merging answers when the terminal
+// switches between canonical and raw modes. The terminal is closed when there is no answer left.
+type promptAnswerer struct {
+	mu      sync.Mutex
+	out     strings.Builder
+	ptmx    *os.File
+	answers []string
+}
+
+var prompts = []string{
+	"Press Enter to continue...",
+	// Secrets, like "Enter recovery key: ".
+	": ",
+	"[y/N] ",
+}
+
+func (p *promptAnswerer) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n, err := p.out.Write(b)
+
+	for _, prompt := range prompts {
+		if !strings.HasSuffix(string(b), prompt) {
+			continue
+		}
+		if len(p.answers) == 0 {
+			p.ptmx.Close()
+			break
+		}
+		fmt.Fprintln(p.ptmx, p.answers[0])
+		p.answers = p.answers[1:]
+	}
+
+	return n, err
+}
+
+func (p *promptAnswerer) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.out.String()
+}
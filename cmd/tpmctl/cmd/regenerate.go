@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 
@@ -16,6 +15,10 @@ func (a App) newRegenerateKeyCmd() *cli.Command {
 		Name:    "regenerate-recovery-key",
 		Usage:   "Regenerate an existing recovery key",
 		Suggest: true,
+		Flags: append([]cli.Flag{
+			containerRoleFlag(),
+			verifyRecoveryKeyFlag(),
+		}, recoveryKeyExportFlags()...),
 		Arguments: []cli.Argument{
 			&cli.StringArg{
 				Name:        "key-id",
@@ -24,7 +27,7 @@ func (a App) newRegenerateKeyCmd() *cli.Command {
 			},
 		},
 		ShellComplete: func(ctx context.Context, cmd *cli.Command) {
-			if alreadyCompleted(cmd) {
+			if a.completeContainerRoles(ctx, cmd) || alreadyCompleted(cmd) {
 				return
 			}
 
@@ -41,23 +44,24 @@ func (a App) newRegenerateKeyCmd() *cli.Command {
 			}
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			stop := a.tui.Spin("Regenerating recovery key...")
+			if err := a.checkRecoveryKeyExport(cmd); err != nil {
+				return err
+			}
+
+			ctx, stop := a.spinChange(ctx, cmd, "Regenerating recovery key...")
 			defer stop()
 
-			recoveryKey, err := a.tpm.RegenerateKey(ctx, recoveryKeyName)
+			recoveryKey, err := a.tpm.RegenerateKey(ctx, recoveryKeyName, containerRoles(cmd))
 			if err != nil {
 				return err
 			}
 			stop()
 
-			fmt.Fprintf(a.tui.Writer(), "Recovery Key: %s\n", recoveryKey)
-
-			// Wait for user to confirm by pressing Enter
-			fmt.Fprint(a.tui.Writer(), "Save the recovery key somewhere safe. Press Enter to continue...")
-			_, _ = bufio.NewReader(a.tui.Reader()).ReadString('\n')
-			a.tui.ClearPreviousLines(2)
+			if isDryRun(cmd) {
+				return a.dryRunDone()
+			}
 
-			return nil
+			return a.handOverRecoveryKey(ctx, cmd, recoveryKeyName, recoveryKey, recoveryKeyContainerRoles(cmd))
 		},
 	}
 }
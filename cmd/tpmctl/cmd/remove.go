@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/urfave/cli/v3"
 )
@@ -14,10 +13,10 @@ func (a App) newRemovePassphraseCmd() *cli.Command {
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Ensure that the user's effective ID is root
 			if !a.isUserRoot() {
-				return fmt.Errorf("this command requires elevated privileges. Please run with sudo")
+				return ErrRootRequired
 			}
 
-			stop := a.tui.Spin("Removing passphrase...")
+			ctx, stop := a.spinChange(ctx, cmd, "Removing passphrase...")
 			defer stop()
 
 			if err := a.tpm.RemovePassphrase(ctx); err != nil {
@@ -25,8 +24,7 @@ func (a App) newRemovePassphraseCmd() *cli.Command {
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "Passphrase removed successfully")
-			return nil
+			return a.reportChanged(cmd, "Passphrase removed successfully")
 		},
 	}
 }
@@ -38,10 +36,10 @@ func (a App) newRemovePINCmd() *cli.Command {
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Ensure that the user's effective ID is root
 			if !a.isUserRoot() {
-				return fmt.Errorf("this command requires elevated privileges. Please run with sudo")
+				return ErrRootRequired
 			}
 
-			stop := a.tui.Spin("Removing PIN...")
+			ctx, stop := a.spinChange(ctx, cmd, "Removing PIN...")
 			defer stop()
 
 			if err := a.tpm.RemovePIN(ctx); err != nil {
@@ -49,8 +47,7 @@ func (a App) newRemovePINCmd() *cli.Command {
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "PIN removed successfully")
-			return nil
+			return a.reportChanged(cmd, "PIN removed successfully")
 		},
 	}
 }
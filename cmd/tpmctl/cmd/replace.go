@@ -2,87 +2,70 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/urfave/cli/v3"
 )
 
-//nolint:dupl // newReplacePassphraseCmd and newReplacePINCmd have similar behaviour
 func (a App) newReplacePassphraseCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "replace-passphrase",
 		Usage: "Replace encryption passphrase",
+		Flags: []cli.Flag{
+			containerRoleFlag(),
+		},
+		ShellComplete: a.shellCompleteWithContainerRoles,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			oldPassphrase, err := a.tui.ReadUserSecret("Enter current passphrase: ")
 			if err != nil {
 				return err
 			}
 
-			newPassphrase, err := a.tui.ReadUserSecret("Enter new passphrase: ")
-			if err != nil {
-				return err
-			}
-
-			confirmPassphrase, err := a.tui.ReadUserSecret("Confirm new passphrase: ")
+			newPassphrase, err := a.readNewSecret(ctx, "passphrase")
 			if err != nil {
 				return err
 			}
 
-			if newPassphrase != confirmPassphrase {
-				return fmt.Errorf("passphrase confirmation does not match")
-			}
-
-			stop := a.tui.Spin("Replacing passphrase...")
+			ctx, stop := a.spinChange(ctx, cmd, "Replacing passphrase...")
 			defer stop()
 
-			if err := a.tpm.ReplacePassphrase(ctx, oldPassphrase, newPassphrase); err != nil {
+			if err := a.tpm.ReplacePassphrase(ctx, oldPassphrase, newPassphrase, containerRoles(cmd)); err != nil {
 				return err
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "Passphrase replaced successfully")
-
-			return nil
+			return a.reportChanged(cmd, "Passphrase replaced successfully")
 		},
 	}
 }
 
-//nolint:dupl // newReplacePassphraseCmd and newReplacePINCmd have similar behaviour
 func (a App) newReplacePINCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "replace-pin",
 		Usage: "Replace encryption PIN",
+		Flags: []cli.Flag{
+			containerRoleFlag(),
+		},
+		ShellComplete: a.shellCompleteWithContainerRoles,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			oldPIN, err := a.tui.ReadUserSecret("Enter current PIN: ")
 			if err != nil {
 				return err
 			}
 
-			newPIN, err := a.tui.ReadUserSecret("Enter new PIN: ")
-			if err != nil {
-				return err
-			}
-
-			confirmPIN, err := a.tui.ReadUserSecret("Confirm new PIN: ")
+			newPIN, err := a.readNewSecret(ctx, "PIN")
 			if err != nil {
 				return err
 			}
 
-			if newPIN != confirmPIN {
-				return fmt.Errorf("PIN confirmation does not match")
-			}
-
-			stop := a.tui.Spin("Replacing PIN...")
+			ctx, stop := a.spinChange(ctx, cmd, "Replacing PIN...")
 			defer stop()
 
-			if err := a.tpm.ReplacePIN(ctx, oldPIN, newPIN); err != nil {
+			if err := a.tpm.ReplacePIN(ctx, oldPIN, newPIN, containerRoles(cmd)); err != nil {
 				return err
 			}
 			stop()
 
-			fmt.Fprintln(a.tui.Writer(), "PIN replaced successfully")
-
-			return nil
+			return a.reportChanged(cmd, "PIN replaced successfully")
 		},
 	}
 }
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/syslog"
+	"maps"
+	"slices"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	"github.com/urfave/cli/v3"
+)
+
+// resetAuthModes are the authentications the platform key can be reset to, with the kind of secret they use.
+var resetAuthModes = map[string]struct {
+	authMode snapd.AuthMode
+	label    string
+	// kind is the kind of the new secret to read, if any.
+	kind string
+}{
+	"pin":        {authMode: snapd.AuthModePIN, label: "PIN", kind: "PIN"},
+	"passphrase": {authMode: snapd.AuthModePassphrase, label: "passphrase", kind: "passphrase"},
+	"none":       {authMode: snapd.AuthModeNone, label: "none"},
+}
+
+func (a App) newResetAuthCmd() *cli.Command {
+	var mode string
+
+	return &cli.Command{
+		Name:  "reset-auth",
+		Usage: "Reset a forgotten PIN or passphrase using a recovery key",
+		Description: "Proves with a recovery key that you own the device, then replaces the authentication of the " +
+			"platform key with a new PIN, a new passphrase, or none, without asking for the current one.",
+		Suggest: true,
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:        "auth",
+				UsageText:   "<pin|passphrase|none>",
+				Destination: &mode,
+			},
+		},
+		ShellComplete: func(ctx context.Context, cmd *cli.Command) {
+			if alreadyCompleted(cmd) {
+				return
+			}
+			for _, m := range slices.Sorted(maps.Keys(resetAuthModes)) {
+				fmt.Fprintln(cmd.Root().Writer, m)
+			}
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			// Ensure that the user's effective ID is root
+			if !a.isUserRoot() {
+				return ErrRootRequired
+			}
+
+			reset, ok := resetAuthModes[mode]
+			if !ok {
+				return fmt.Errorf("invalid authentication %q, must be pin, passphrase or none", mode)
+			}
+
+			a.auditReset(ctx, reset.authMode, fmt.Sprintf("Resetting authentication to %s with a recovery key", reset.label))
+
+			key, err := a.tui.ReadRecoveryKey()
+			if err != nil {
+				return err
+			}
+
+			stop := a.tui.Spin("Checking recovery key...")
+			defer stop()
+
+			works, err := a.tpm.CheckKey(ctx, key, nil)
+			if err != nil {
+				stop()
+				a.auditReset(ctx, reset.authMode, "Recovery key could not be checked")
+				return err
+			}
+			stop()
+
+			if !works {
+				a.auditReset(ctx, reset.authMode, "Recovery key refused")
+				return fmt.Errorf("%w: it does not unlock the encrypted containers, nothing was changed", tpm.ErrInvalidRecoveryKey)
+			}
+			a.auditReset(ctx, reset.authMode, "Recovery key verified")
+
+			var secret string
+			if reset.kind != "" {
+				if secret, err = a.readNewSecret(ctx, reset.kind); err != nil {
+					return err
+				}
+			}
+
+			ctx, stop = a.spinChange(ctx, cmd, fmt.Sprintf("Resetting authentication to %s...", reset.label))
+			defer stop()
+
+			if err := a.tpm.ResetAuth(ctx, reset.authMode, secret); err != nil {
+				stop()
+				a.auditReset(ctx, reset.authMode, "Authentication reset failed")
+				return err
+			}
+			stop()
+
+			if isDryRun(cmd) {
+				return a.dryRunDone()
+			}
+			a.auditReset(ctx, reset.authMode, fmt.Sprintf("Authentication reset to %s", reset.label))
+
+			return nil
+		},
+	}
+}
+
+// auditReset shows a step of an authentication reset to the user and records it in the system log, as resetting
+// bypasses the current authentication of the platform key.
+func (a App) auditReset(ctx context.Context, authMode snapd.AuthMode, step string) {
+	fmt.Fprintln(a.tui.Writer(), step)
+
+	record := fmt.Sprintf("reset-auth to %s: %s", authMode, step)
+
+	w := a.auditLog
+	if w == nil {
+		sw, err := syslog.New(syslog.LOG_AUTH|syslog.LOG_NOTICE, "snap-tpmctl")
+		if err != nil {
+			// Warnings are always shown, so that the record is not lost.
+			log.Warn(ctx, "Could not record in the system log: %v: %s", err, record)
+			return
+		}
+		defer sw.Close()
+		w = sw
+	}
+
+	if _, err := fmt.Fprintln(w, record); err != nil {
+		log.Warn(ctx, "Could not record in the system log: %v: %s", err, record)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+)
+
+func TestResetAuth(t *testing.T) {
+	t.Parallel()
+
+	const recoveryKey = "11272-47509-28031-54818-41671-38673-11053-06376"
+
+	tests := map[string]struct {
+		args      []string
+		answers   []string
+		admineUID int
+
+		wantInBody   string
+		wantNoChange bool
+		wantErr      bool
+	}{
+		"Success_resetting_to_PIN":        {args: []string{"pin"}, answers: []string{recoveryKey, "12345", "12345"}, wantInBody: "12345"},
+		"Success_resetting_to_passphrase": {args: []string{"passphrase"}, answers: []string{recoveryKey, "test", "test"}, wantInBody: "test"},
+		"Success_removing_authentication": {args: []string{"none"}, answers: []string{recoveryKey}, wantInBody: `"auth-mode":"none"`},
+		"Success_in_dry_run":              {args: []string{"--dry-run", "none"}, answers: []string{recoveryKey}, wantNoChange: true},
+
+		"Error_on_user_privilege":               {args: []string{"pin"}, admineUID: 1, wantNoChange: true, wantErr: true},
+		"Error_on_missing_authentication":       {wantNoChange: true, wantErr: true},
+		"Error_on_invalid_authentication":       {args: []string{"fingerprint"}, wantNoChange: true, wantErr: true},
+		"Error_when_recovery_key_does_not_work": {args: []string{"pin"}, answers: []string{recoveryKey}, wantNoChange: true, wantErr: true},
+		"Error_checking_recovery_key":           {args: []string{"pin"}, answers: []string{recoveryKey}, wantNoChange: true, wantErr: true},
+		"Error_on_confirmation_mismatch":        {args: []string{"pin"}, answers: []string{recoveryKey, "12345", "54321"}, wantNoChange: true, wantErr: true},
+		"Error_on_weak_PIN":                     {args: []string{"pin"}, answers: []string{recoveryKey, "1", "2", "3"}, wantNoChange: true, wantErr: true},
+		"Error_when_resetting":                  {args: []string{"none"}, answers: []string{recoveryKey}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+			var audit bytes.Buffer
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(append([]string{"reset-auth"}, tc.args...)...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+				cmdtestutils.WithEuid(tc.admineUID),
+				cmdtestutils.WithAuditLog(&audit),
+			)
+
+			err = app.Run(ctx)
+
+			// Changes which would be made are reported with the exit status, which is not an error.
+			if _, ok := errors.AsType[cmd.ExitStatusError](err); ok {
+				err = nil
+			}
+
+			// The recovery key and the new secret are only checked until the authentication is reset.
+			if tc.wantNoChange {
+				for _, r := range c.Requests {
+					is.True(r.Method == "GET" || strings.Contains(r.Body, `"action":"check-`)) // Request does not change the system
+				}
+			}
+
+			// Every step shown to the user is recorded in the audit log.
+			got := fmt.Sprintf("%s\nAudit log:\n%s", out.String(), audit.String())
+			if testutils.CheckError(is, err, tc.wantErr) {
+				got = fmt.Sprintf("%s\nError: %s", got, err)
+				golden.CheckOrUpdate(t, got) // TestResetAuth fails with the expected output
+				return
+			}
+
+			if tc.wantInBody != "" {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, `"action":"replace-platform-key"`, tc.wantInBody)
+			}
+
+			golden.CheckOrUpdate(t, got) // TestResetAuth returns the expected output
+		})
+	}
+}
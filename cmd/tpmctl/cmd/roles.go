@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+)
+
+// containerRoleFlag returns the repeatable flag restricting an operation to some containers.
+func containerRoleFlag() *cli.StringSliceFlag {
+	return &cli.StringSliceFlag{
+		Name:  "container-role",
+		Usage: "Only target the container with this role, like system-data or system-save. Can be repeated",
+	}
+}
+
+// containerRoles returns the deduplicated container roles requested on the command line.
+func containerRoles(cmd *cli.Command) []string {
+	roles := slices.Clone(cmd.StringSlice("container-role"))
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// completeContainerRoles prints the container roles of the system if the value of the container role flag is being completed.
+// It returns false if something else is being completed.
+func (a App) completeContainerRoles(ctx context.Context, cmd *cli.Command) bool {
+	if n := len(a.args); n < 2 || a.args[n-1] != "--generate-shell-completion" || a.args[n-2] != "--container-role" {
+		return false
+	}
+
+	roles, err := a.tpm.ContainerRoles(ctx)
+	if err != nil {
+		return true
+	}
+
+	for _, role := range roles {
+		fmt.Fprintf(cmd.Root().Writer, "%s\n", role)
+	}
+
+	return true
+}
+
+// shellCompleteWithContainerRoles completes the values of the container role flag, or falls back to the default completion.
+func (a App) shellCompleteWithContainerRoles(ctx context.Context, cmd *cli.Command) {
+	if a.completeContainerRoles(ctx, cmd) {
+		return
+	}
+
+	cli.DefaultCompleteWithFlags(ctx, cmd)
+}
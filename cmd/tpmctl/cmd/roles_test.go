@@ -0,0 +1,52 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/matryer/is"
+)
+
+func TestContainerRoleCompletion(t *testing.T) {
+	t.Parallel()
+
+	commands := []string{
+		"create-recovery-key",
+		"regenerate-recovery-key",
+		"check-recovery-key",
+		"replace-passphrase",
+		"replace-pin",
+	}
+
+	for _, command := range commands {
+		t.Run(command, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			var out strings.Builder
+			tui := tui.New(nil, &out)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(command, "--container-role", "--generate-shell-completion"),
+				cmdtestutils.WithTui(tui),
+			)
+
+			err := app.Run(ctx)
+			is.NoErr(err)
+
+			is.Equal(out.String(), "system-data\nsystem-save\n") // Completion lists the encrypted container roles
+		})
+	}
+}
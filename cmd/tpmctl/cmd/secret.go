@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/urfave/cli/v3"
+)
+
+// secretInputFlags are the global flags selecting where secrets are read from instead of the terminal.
+func secretInputFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "secret-stdin",
+			Usage: "Read secrets from the standard input, one per line, instead of prompting for them",
+		},
+		&cli.IntFlag{
+			Name:  "secret-fd",
+			Usage: "Read secrets from the given file descriptor, one per line, instead of prompting for them",
+		},
+		&cli.StringFlag{
+			Name:      "secret-file",
+			Usage:     "Read secrets from the given file, one per line, instead of prompting for them",
+			TakesFile: true,
+		},
+	}
+}
+
+// setupSecretSource makes the tui read secrets from the input selected on the command line, if any.
+// The returned function releases that input.
+func (a App) setupSecretSource(ctx context.Context, cmd *cli.Command) (release func() error, err error) {
+	release = func() error { return nil }
+
+	var set []string
+	for _, name := range []string{"secret-stdin", "secret-fd", "secret-file"} {
+		if cmd.IsSet(name) {
+			set = append(set, "--"+name)
+		}
+	}
+	if len(set) > 1 {
+		return release, fmt.Errorf("only one secret input can be used, got %v", set)
+	}
+
+	var f *os.File
+	switch {
+	case cmd.Bool("secret-stdin"):
+		// Stdin is not ours to close.
+		a.tui.SetSecretSource(a.tui.Reader())
+		if stdin, ok := a.tui.Reader().(*os.File); ok {
+			warnOnOpenPermissions(ctx, stdin)
+		}
+		return release, nil
+	case cmd.IsSet("secret-fd"):
+		fd := cmd.Int("secret-fd")
+		if fd < 0 {
+			return release, fmt.Errorf("invalid secret file descriptor %d", fd)
+		}
+		f = os.NewFile(uintptr(fd), fmt.Sprintf("file descriptor %d", fd))
+		if _, err := f.Stat(); err != nil {
+			return release, fmt.Errorf("could not read secrets from file descriptor %d: %w", fd, err)
+		}
+	case cmd.IsSet("secret-file"):
+		f, err = os.Open(cmd.String("secret-file"))
+		if err != nil {
+			return release, fmt.Errorf("could not read secrets from file: %w", err)
+		}
+	default:
+		return release, nil
+	}
+
+	warnOnOpenPermissions(ctx, f)
+	a.tui.SetSecretSource(f)
+
+	// Like stdin, the standard streams are not ours to close.
+	if f.Fd() <= 2 {
+		return release, nil
+	}
+
+	return f.Close, nil
+}
+
+// warnOnOpenPermissions warns if f is a regular file which can be accessed by other users than its owner.
+func warnOnOpenPermissions(ctx context.Context, f *os.File) {
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		return
+	}
+
+	if perm := fi.Mode().Perm(); perm&0o077 != 0 {
+		log.Warn(ctx, "Secrets in %s are accessible by other users (permissions %#o), restrict them with chmod 600", f.Name(), perm)
+	}
+}
+
+// maxSecretAttempts is how many times a new secret is asked when the previous ones are too weak.
+const maxSecretAttempts = 3
+
+// readNewSecret reads a new secret of the given kind, and its confirmation when it is typed in the terminal.
+// Typed secrets are checked first: their strength is shown, and another one is asked when they are too weak.
+func (a App) readNewSecret(ctx context.Context, kind string) (string, error) {
+	// Nobody is there to make typos, nor to choose another secret: the operation checks it.
+	if !a.tui.Interactive() {
+		return a.tui.ReadUserSecret(fmt.Sprintf("Enter new %s: ", kind))
+	}
+
+	check := a.secretCheck(kind)
+	for attempt := 1; ; attempt++ {
+		secret, err := a.tui.ReadUserSecret(fmt.Sprintf("Enter new %s: ", kind))
+		if err != nil {
+			return "", err
+		}
+
+		quality, err := check(ctx, secret)
+		if refused, hint, ok := qualityRefusal(err); ok {
+			a.tui.DisplayStrength(refused.Entropy, refused.MinEntropy, refused.OptimalEntropy)
+			if attempt < maxSecretAttempts {
+				fmt.Fprintf(a.tui.Writer(), "%s\n", hint)
+				continue
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		// Older versions of snapd don't report the quality.
+		if quality.MinEntropy > 0 {
+			a.tui.DisplayStrength(quality.Entropy, quality.MinEntropy, quality.OptimalEntropy)
+		}
+
+		confirm, err := a.tui.ReadUserSecret(fmt.Sprintf("Confirm new %s: ", kind))
+		if err != nil {
+			return "", err
+		}
+
+		if secret != confirm {
+			return "", fmt.Errorf("%s confirmation does not match", kind)
+		}
+
+		return secret, nil
+	}
+}
+
+// secretCheck returns the function checking the quality of a new secret of the given kind.
+func (a App) secretCheck(kind string) func(context.Context, string) (snapd.Quality, error) {
+	if kind == "PIN" {
+		return a.tpm.CheckPIN
+	}
+	return a.tpm.CheckPassphrase
+}
+
+// qualityRefusal returns the quality of the secret refused with err and the explanation of the refusal,
+// if it was refused by the quality checks.
+func qualityRefusal(err error) (quality snapd.Quality, hint string, ok bool) {
+	snapdErr, ok := errors.AsType[*snapd.Error](err)
+	if !ok {
+		return quality, "", false
+	}
+
+	if quality, ok = snapdErr.Quality(); !ok {
+		return quality, "", false
+	}
+	hint, _ = snapd.Explain(snapdErr)
+
+	return quality, hint, true
+}
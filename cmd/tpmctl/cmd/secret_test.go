@@ -0,0 +1,125 @@
+package cmd_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/matryer/is"
+)
+
+func TestSecretInput(t *testing.T) {
+	t.Parallel()
+
+	const recoveryKey = "11272-47509-28031-54818-41671-38673-11053-06376"
+
+	tests := map[string]struct {
+		args     []string
+		secrets  string
+		filePerm os.FileMode
+
+		wantInLogs string
+		wantErr    bool
+	}{
+		"Add_PIN_from_stdin":                   {args: []string{"--secret-stdin", "add-pin"}, secrets: "12345\n"},
+		"Add_PIN_from_stdin_set_after_command": {args: []string{"add-pin", "--secret-stdin"}, secrets: "12345\n"},
+		"Add_PIN_from_file":                    {args: []string{"--secret-file", "{file}", "add-pin"}, secrets: "12345\n"},
+		"Add_PIN_from_file_descriptor":         {args: []string{"--secret-fd", "{fd}", "add-pin"}, secrets: "12345\n"},
+		"Replace_PIN_from_file":                {args: []string{"--secret-file", "{file}", "replace-pin"}, secrets: "12345\n54321\n"},
+		"Check_recovery_key_from_file":         {args: []string{"--secret-file", "{file}", "check-recovery-key"}, secrets: recoveryKey + "\n"},
+
+		"Warns_on_secret_file_accessible_by_others": {
+			args:       []string{"--secret-file", "{file}", "add-pin"},
+			secrets:    "12345\n",
+			filePerm:   0o644,
+			wantInLogs: "accessible by other users",
+		},
+
+		"Error_on_multiple_secret_inputs":  {args: []string{"--secret-stdin", "--secret-file", "{file}", "add-pin"}, wantErr: true},
+		"Error_on_missing_secret_file":     {args: []string{"--secret-file", "/does/not/exist", "add-pin"}, wantErr: true},
+		"Error_on_invalid_file_descriptor": {args: []string{"--secret-fd", "9999", "add-pin"}, wantErr: true},
+		"Error_when_secrets_are_missing":   {args: []string{"--secret-file", "{file}", "replace-pin"}, secrets: "12345\n", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			if tc.filePerm == 0 {
+				tc.filePerm = 0o600
+			}
+			path := filepath.Join(t.TempDir(), "secrets")
+			err := os.WriteFile(path, []byte(tc.secrets), tc.filePerm)
+			is.NoErr(err) // Setup: could not write secrets file
+			// Ignore the umask.
+			err = os.Chmod(path, tc.filePerm)
+			is.NoErr(err) // Setup: could not set secrets file permissions
+
+			f, err := os.Open(path)
+			is.NoErr(err) // Setup: could not open secrets file
+			defer f.Close()
+
+			var args []string
+			for _, arg := range tc.args {
+				switch arg {
+				case "{file}":
+					arg = path
+				case "{fd}":
+					// The command closes the file descriptor it reads from: hand it a copy of ours.
+					fd, err := syscall.Dup(int(f.Fd()))
+					is.NoErr(err) // Setup: could not duplicate secrets file descriptor
+					arg = strconv.Itoa(fd)
+				}
+				args = append(args, arg)
+			}
+
+			stdin, w, err := os.Pipe()
+			is.NoErr(err) // Setup: could not create stdin pipe
+			defer stdin.Close()
+			go func() {
+				defer w.Close()
+				fmt.Fprint(w, tc.secrets)
+			}()
+
+			var out strings.Builder
+			tui := tui.New(stdin, &out)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui),
+				cmdtestutils.WithEuid(0),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			if tc.wantInLogs != "" {
+				is.True(strings.Contains(logs.String(), tc.wantInLogs)) // Expected warning is logged
+			} else {
+				is.True(logs.Len() == 0) // No logs printed by default
+			}
+
+			golden.CheckOrUpdate(t, out.String()) // TestSecretInput returns the correct output without prompts
+		})
+	}
+}
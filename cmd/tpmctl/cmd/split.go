@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/snap-tpmctl/internal/shamir"
+	"github.com/snapcore/secboot"
+	"github.com/urfave/cli/v3"
+)
+
+// splitFlag splits a new recovery key into shares among custodians.
+func splitFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:  "split",
+		Usage: "Only hand over the recovery key as `N-of-M` shares, any N of them being needed to recover it",
+		Validator: func(s string) error {
+			_, _, err := parseSplit(s)
+			return err
+		},
+	}
+}
+
+// parseSplit parses a "N-of-M" split specification.
+func parseSplit(s string) (n, m int, err error) {
+	before, after, found := strings.Cut(s, "-of-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid split %q, must be like 3-of-5", s)
+	}
+
+	n, errN := strconv.Atoi(before)
+	m, errM := strconv.Atoi(after)
+	if errN != nil || errM != nil {
+		return 0, 0, fmt.Errorf("invalid split %q, must be like 3-of-5", s)
+	}
+
+	if n < 2 || m < n || m > shamir.MaxShares {
+		return 0, 0, fmt.Errorf("invalid split %q, must have 2 <= N <= M <= %d", s, shamir.MaxShares)
+	}
+
+	return n, m, nil
+}
+
+// checkSplit validates that the recovery key is not exposed as a whole when it is split.
+func checkSplit(cmd *cli.Command) error {
+	if cmd.String("split") == "" {
+		return nil
+	}
+
+	for _, name := range []string{"qr", "sheet", "escrow-recipient", "escrow-dir", "escrow-server"} {
+		if cmd.IsSet(name) {
+			return fmt.Errorf("--split can't be used with --%s, which exposes the whole recovery key", name)
+		}
+	}
+
+	return nil
+}
+
+// handOverRecoveryKeyShares splits the recovery key and prints each share separately,
+// so that each custodian only sees their own share. The whole key is never printed.
+func (a App) handOverRecoveryKeyShares(cmd *cli.Command, recoveryKey string) error {
+	n, m, err := parseSplit(cmd.String("split"))
+	if err != nil {
+		return err
+	}
+
+	key, err := secboot.ParseRecoveryKey(recoveryKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse recovery key: %w", err)
+	}
+
+	shares, err := shamir.Split(key[:], n, m)
+	if err != nil {
+		return fmt.Errorf("failed to split recovery key: %w", err)
+	}
+
+	// Nobody is there to confirm: keep the shares printed.
+	if !a.tui.Interactive() {
+		for _, s := range shares {
+			fmt.Fprintf(a.tui.Writer(), "Recovery Key Share %d of %d: %s\n", s.Index, m, s)
+		}
+		return nil
+	}
+
+	for _, s := range shares {
+		fmt.Fprintf(a.tui.Writer(), "Recovery Key Share %d of %d: %s\n", s.Index, m, s)
+
+		// Wait for the custodian to confirm by pressing Enter
+		fmt.Fprint(a.tui.Writer(), "Hand this share to its custodian only. Press Enter to continue...")
+		_ = a.tui.WaitForEnter()
+		a.tui.ClearPreviousLines(2)
+	}
+
+	fmt.Fprintf(a.tui.Writer(), "Recovery key split into %d shares. Any %d of them recover it with combine-recovery-key\n", m, n)
+
+	return nil
+}
+
+func (a App) newCombineKeyCmd() *cli.Command {
+	return &cli.Command{
+		Name:    "combine-recovery-key",
+		Usage:   "Recover a split recovery key from its shares",
+		Suggest: true,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			key, err := a.combineRecoveryKeyShares()
+			if err != nil {
+				return err
+			}
+
+			// Print the bare key when nobody is there, so that it can be fed to other commands.
+			if !a.tui.Interactive() {
+				fmt.Fprintln(a.tui.Writer(), key)
+				return nil
+			}
+
+			fmt.Fprintf(a.tui.Writer(), "Recovery Key: %s\n", key)
+
+			return nil
+		},
+	}
+}
+
+// combineRecoveryKeyShares reads shares until there are enough of them to recover the key.
+// The number of needed shares is known from the first one.
+func (a App) combineRecoveryKeyShares() (string, error) {
+	var shares []shamir.Share
+
+	for threshold := 1; len(shares) < threshold; {
+		prompt := fmt.Sprintf("Enter share %d: ", len(shares)+1)
+		if len(shares) > 0 {
+			prompt = fmt.Sprintf("Enter share %d of %d: ", len(shares)+1, threshold)
+		}
+
+		input, err := a.tui.ReadRecoveryKeyShare(prompt)
+		if err != nil {
+			return "", err
+		}
+		if input == "" && a.tui.Interactive() {
+			return "", errors.New("recovery key combination cancelled")
+		}
+
+		share, err := checkShare(input, shares)
+		if err != nil {
+			// Typing mistakes can be fixed right away.
+			if a.tui.Interactive() {
+				fmt.Fprintf(a.tui.Writer(), "%v, try again\n", err)
+				continue
+			}
+			return "", err
+		}
+
+		shares = append(shares, share)
+		threshold = int(share.Threshold)
+	}
+
+	secret, err := shamir.Combine(shares)
+	if err != nil {
+		return "", fmt.Errorf("failed to combine recovery key shares: %w", err)
+	}
+
+	var key secboot.RecoveryKey
+	if len(secret) != len(key) {
+		return "", errors.New("failed to combine recovery key shares: shares are not from a recovery key")
+	}
+	copy(key[:], secret)
+
+	return key.String(), nil
+}
+
+// checkShare parses a share and checks that it belongs with the ones already entered.
+func checkShare(input string, shares []shamir.Share) (shamir.Share, error) {
+	share, err := shamir.ParseShare(input)
+	if err != nil {
+		return share, err
+	}
+
+	for _, s := range shares {
+		if s.Threshold != share.Threshold || len(s.Value) != len(share.Value) {
+			return share, errors.New("share is not from the same recovery key")
+		}
+		if s.Index == share.Index {
+			return share, fmt.Errorf("share %d was already entered", share.Index)
+		}
+	}
+
+	return share, nil
+}
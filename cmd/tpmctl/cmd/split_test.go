@@ -0,0 +1,170 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	cmdtestutils "github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/shamir"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tui"
+	"github.com/creack/pty"
+	"github.com/matryer/is"
+	"github.com/snapcore/secboot"
+)
+
+func TestSplitRecoveryKey(t *testing.T) {
+	t.Parallel()
+
+	const key = "11272-47509-28031-54818-41671-38673-11053-06376"
+
+	tests := map[string]struct {
+		split       string
+		extraArgs   []string
+		secretStdin bool
+
+		wantShares int
+		wantErr    bool
+	}{
+		"Success_splitting_2_of_3": {split: "2-of-3", wantShares: 3},
+		"Success_splitting_3_of_3": {split: "3-of-3", wantShares: 3},
+		"Success_splitting_2_of_5": {split: "2-of-5", wantShares: 5},
+
+		"Error_on_invalid_split":           {split: "3", wantErr: true},
+		"Error_on_threshold_of_1":          {split: "1-of-3", wantErr: true},
+		"Error_on_less_shares_than_needed": {split: "4-of-3", wantErr: true},
+		"Error_on_too_many_shares":         {split: "2-of-256", wantErr: true},
+		"Error_when_combined_with_qr":      {split: "2-of-3", extraArgs: []string{"--qr"}, wantErr: true},
+		"Error_when_combined_with_sheet":   {split: "2-of-3", extraArgs: []string{"--sheet", "sheet.html"}, wantErr: true},
+		"Error_when_combined_with_escrow":  {split: "2-of-3", extraArgs: []string{"--escrow-dir", "."}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: make([]string, tc.wantShares)}
+			tui := tui.New(tty, out)
+
+			args := append([]string{"create-recovery-key", "test", "--split", tc.split}, tc.extraArgs...)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+			app := cmd.New(
+				cmdtestutils.WithSnapTPM(s),
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				is.Equal(len(c.Requests), 0) // No key is created when it can't be split
+				return
+			}
+
+			is.True(logs.Len() == 0) // No logs printed by default
+
+			got := out.String()
+			is.True(!strings.Contains(got, key)) // The whole recovery key is never printed
+
+			matches := regexp.MustCompile(`Recovery Key Share \d+ of \d+: ([0-9a-f-]+)`).FindAllStringSubmatch(got, -1)
+			is.Equal(len(matches), tc.wantShares) // Each share is printed
+
+			var shares []shamir.Share
+			for _, m := range matches {
+				share, err := shamir.ParseShare(m[1])
+				is.NoErr(err) // Printed shares can be parsed
+				shares = append(shares, share)
+			}
+
+			secret, err := shamir.Combine(shares[len(shares)-int(shares[0].Threshold):])
+			is.NoErr(err) // Any threshold number of shares can be combined
+			var recovered secboot.RecoveryKey
+			copy(recovered[:], secret)
+			is.Equal(recovered.String(), key) // Shares recover the recovery key
+		})
+	}
+}
+
+func TestCombineRecoveryKey(t *testing.T) {
+	t.Parallel()
+
+	const (
+		share1     = "0201-752e-dde5-f613-aa1b-af67-a880-f66a-d493-8d89"
+		share2     = "0202-f228-0501-7691-2957-1733-78b9-80a9-9015-6916"
+		share3     = "0203-8f2a-4d5d-ffef-a19a-7ff6-c1ae-5be8-ac9e-454f"
+		otherShare = "0301-4fde-5ddc-0ada-3d68-d9d6-29f3-714f-b7cb-2f44"
+		typo       = "0201-752e-dde5-f613-aa1b-af67-a880-f66a-d493-8d88"
+	)
+
+	tests := map[string]struct {
+		answers    []string
+		secretFile string
+
+		wantErr bool
+	}{
+		"Success_combining_shares":                  {answers: []string{share1, share3}},
+		"Success_combining_shares_in_any_order":     {answers: []string{share3, share2}},
+		"Success_after_a_typo":                      {answers: []string{typo, share1, share2}},
+		"Success_after_entering_a_share_twice":      {answers: []string{share1, share1, share2}},
+		"Success_after_a_share_of_another_key":      {answers: []string{share1, otherShare, share2}},
+		"Success_combining_shares_from_secret_file": {secretFile: share1 + "\n" + share2 + "\n"},
+
+		"Error_when_combination_is_cancelled":       {answers: []string{share1, "\x03"}, wantErr: true},
+		"Error_reading_share":                       {answers: []string{share1}, wantErr: true},
+		"Error_on_typo_in_secret_file":              {secretFile: typo + "\n" + share2 + "\n", wantErr: true},
+		"Error_on_not_enough_shares_in_secret_file": {secretFile: share1 + "\n", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			out := &promptAnswerer{ptmx: ptmx, answers: tc.answers}
+
+			args := []string{"combine-recovery-key"}
+			if tc.secretFile != "" {
+				p := filepath.Join(t.TempDir(), "shares")
+				err := os.WriteFile(p, []byte(tc.secretFile), 0o600)
+				is.NoErr(err) // Setup: could not write secret file
+				args = append([]string{"--secret-file", p}, args...)
+			}
+
+			app := cmd.New(
+				cmdtestutils.WithArgs(args...),
+				cmdtestutils.WithTui(tui.New(tty, out)),
+			)
+
+			err = app.Run(ctx)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			golden.CheckOrUpdate(t, out.String()) // TestCombineRecoveryKey returns the expected output
+		})
+	}
+}
@@ -19,6 +19,13 @@ func (a App) newStatusCmd() *cli.Command {
 				return err
 			}
 
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(a.tui.Writer(), format, statusOutput{
+					SchemaVersion: schemaVersion,
+					Status:        status,
+				})
+			}
+
 			fmt.Fprintf(a.tui.Writer(), "The FDE system is %s\n", strings.ToUpper(status))
 
 			return nil
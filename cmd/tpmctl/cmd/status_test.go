@@ -19,11 +19,16 @@ func TestStatus(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
+		format string
+
 		wantErr bool
 	}{
-		"Returns_FDE_status": {},
+		"Returns_FDE_status":         {},
+		"Returns_FDE_status_as_json": {format: "json"},
+		"Returns_FDE_status_as_yaml": {format: "yaml"},
 
 		"Error_when_getting_FDE_status": {wantErr: true},
+		"Error_on_unsupported_format":   {format: "xml", wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -33,7 +38,10 @@ func TestStatus(t *testing.T) {
 			is := is.New(t)
 			ctx, logs := testutils.TestLoggerWithBuffer(t)
 
-			command := "status"
+			args := []string{"status"}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
 
 			var out strings.Builder
 			tui := tui.New(nil, &out)
@@ -42,7 +50,7 @@ func TestStatus(t *testing.T) {
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 			app := cmd.New(
 				cmdtestutils.WithSnapTPM(s),
-				cmdtestutils.WithArgs(command),
+				cmdtestutils.WithArgs(args...),
 				cmdtestutils.WithTui(tui),
 			)
 
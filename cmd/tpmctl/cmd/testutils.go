@@ -2,6 +2,9 @@
 package cmd
 
 import (
+	"io"
+
+	"github.com/canonical/snap-tpmctl/internal/escrow"
 	"github.com/canonical/snap-tpmctl/internal/testutils/testsdetection"
 	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/canonical/snap-tpmctl/internal/tui"
@@ -38,3 +41,35 @@ func withTui(t tui.Tui) Option {
 		o.tui = t
 	}
 }
+
+// withRand allows you to specify a custom source of randomness for generated secrets for testing purposes.
+func withRand(r io.Reader) Option {
+	testsdetection.MustBeTesting()
+	return func(o *option) {
+		o.rand = r
+	}
+}
+
+// withEscrowQueueDir allows you to specify a custom escrow queue directory for testing purposes.
+func withEscrowQueueDir(dir string) Option {
+	testsdetection.MustBeTesting()
+	return func(o *option) {
+		o.escrowQueueDir = dir
+	}
+}
+
+// withEscrowClientOptions allows you to specify custom escrow client options for testing purposes.
+func withEscrowClientOptions(opts ...escrow.ClientOption) Option {
+	testsdetection.MustBeTesting()
+	return func(o *option) {
+		o.escrowClientOptions = opts
+	}
+}
+
+// withAuditLog allows you to specify where audited workflows are recorded instead of the system log for testing purposes.
+func withAuditLog(w io.Writer) Option {
+	testsdetection.MustBeTesting()
+	return func(o *option) {
+		o.auditLog = w
+	}
+}
@@ -2,9 +2,11 @@
 package cmdtestutils
 
 import (
+	"io"
 	_ "unsafe" // Required for go:linkname directives
 
 	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
 	"github.com/canonical/snap-tpmctl/internal/testutils/testsdetection"
 	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/canonical/snap-tpmctl/internal/tui"
@@ -33,3 +35,24 @@ func WithSnapTPM(t tpm.SnapTPM) cmd.Option
 //
 //go:linkname WithTui github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd.withTui
 func WithTui(t tui.Tui) cmd.Option
+
+// WithRand is an option that configures the app to generate secrets with the provided source of randomness.
+//
+//go:linkname WithRand github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd.withRand
+func WithRand(r io.Reader) cmd.Option
+
+// WithEscrowQueueDir is an option that configures the app to queue escrow records in the provided directory.
+//
+//go:linkname WithEscrowQueueDir github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd.withEscrowQueueDir
+func WithEscrowQueueDir(dir string) cmd.Option
+
+// WithEscrowClientOptions is an option that configures the app to create escrow clients with the provided options.
+//
+//go:linkname WithEscrowClientOptions github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd.withEscrowClientOptions
+func WithEscrowClientOptions(opts ...escrow.ClientOption) cmd.Option
+
+// WithAuditLog is an option that configures the app to record audited workflows to the provided writer instead of
+// the system log.
+//
+//go:linkname WithAuditLog github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd.withAuditLog
+func WithAuditLog(w io.Writer) cmd.Option
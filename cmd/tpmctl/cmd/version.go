@@ -12,6 +12,13 @@ func newVersionCmd() *cli.Command {
 		Usage:   "Print version",
 		Suggest: true,
 		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if format := outputFormat(cmd); format != formatTable {
+				return writeStructured(cmd.Root().Writer, format, versionOutput{
+					SchemaVersion: schemaVersion,
+					Version:       cmd.Root().Version,
+				})
+			}
+
 			cli.DefaultPrintVersion(cmd.Root())
 
 			return nil
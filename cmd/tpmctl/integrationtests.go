@@ -22,7 +22,7 @@ func init() {
 
 	os.Setenv("SNAP", root)
 
-	syscall := &tpmtestutils.TestSyscall{WantErr: testutils.GetSyscallErrEnv()}
+	syscall := &tpmtestutils.TestSyscall{Root: root, WantErr: testutils.GetSyscallErrEnv()}
 
 	c := snapdtestutils.NewMockSnapdServerWithPath(root)
 	s := tpm.New(
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/testutils"
@@ -22,10 +23,12 @@ func TestAdd(t *testing.T) {
 
 	tests := map[string]struct {
 		nonRootUser bool
+		secretStdin bool
 
 		wantErr bool
 	}{
-		"Success": {},
+		"Success":                         {},
+		"Success_with_secrets_from_stdin": {secretStdin: true},
 
 		"Error_on_user_privilege": {nonRootUser: true, wantErr: true},
 		"Error_wrong_auth_mode":   {wantErr: true},
@@ -63,10 +66,19 @@ func TestAdd(t *testing.T) {
 					}
 				}()
 
+				args := []string{command}
+				if tc.secretStdin {
+					args = append(args, "--secret-stdin")
+				}
+
 				//nolint:gosec // The test intentionally executes the binary built in TestMain.
-				cmd := exec.Command(cmdPath, command)
+				cmd := exec.Command(cmdPath, args...)
 				cmd.Env = append(cmd.Env, testutils.WithRootDir(root), user)
 				cmd.Stdin = tty
+				if tc.secretStdin {
+					// No terminal is involved when secrets are piped in.
+					cmd.Stdin = strings.NewReader(input + "\n")
+				}
 
 				out, err := cmd.CombinedOutput()
 				if testutils.CheckError(is, err, tc.wantErr) {
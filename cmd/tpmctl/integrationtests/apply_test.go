@@ -0,0 +1,64 @@
+package main_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		state string
+
+		wantExitCode int
+	}{
+		"Success_when_system_matches_state": {state: "auth: passphrase\nrecovery-keys:\n  - name: default-recovery\n"},
+		"Success_planning_changes":          {state: "auth: pin\nrecovery-keys:\n  - name: ops-2026\n", wantExitCode: 2},
+
+		"Error_on_invalid_state_file": {state: "auth: fingerprint\n", wantExitCode: 1},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			path := filepath.Join(t.TempDir(), "state.yaml")
+			err := os.WriteFile(path, []byte(tc.state), 0o600)
+			is.NoErr(err) // Setup: could not write state file
+
+			root, err := filepath.Abs(testutils.TestPath(t))
+			is.NoErr(err) // Setup: could not find test path
+
+			//nolint:gosec // The test intentionally executes the binary built in TestMain.
+			cmd := exec.Command(cmdPath, "apply", "--plan", path)
+			cmd.Env = append(cmd.Env, testutils.WithRootDir(root), testutils.WithUserAsNonRoot())
+
+			out, err := cmd.CombinedOutput()
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				err = nil
+				is.Equal(exitErr.ExitCode(), tc.wantExitCode) // Exit code reports whether the system did not match
+			} else {
+				is.Equal(tc.wantExitCode, 0) // Exit code reports whether the system did not match
+			}
+			is.NoErr(err) // Command runs
+
+			if tc.wantExitCode == 1 {
+				return
+			}
+
+			golden.CheckOrUpdate(t, out)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	escrowtestutils "github.com/canonical/snap-tpmctl/internal/escrow/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+)
+
+func TestEscrowDecrypt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		identity string
+
+		wantErr bool
+	}{
+		"Success_decrypting_escrow_file": {},
+
+		"Error_on_identity_of_another_recipient": {identity: "other-identity", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			if tc.identity == "" {
+				tc.identity = "identity"
+			}
+
+			dir, err := filepath.Abs(testutils.TestFamilyPath(t))
+			is.NoErr(err) // Setup: could not find test path
+
+			args := []string{
+				"escrow", "decrypt",
+				"--identity", filepath.Join(dir, tc.identity),
+				filepath.Join(dir, "my-host-my-key-20261017T093000Z.age"),
+			}
+
+			//nolint:gosec // The test intentionally executes the binary built in TestMain.
+			cmd := exec.Command(cmdPath, args...)
+			cmd.Env = append(cmd.Env, testutils.WithRootDir(dir), testutils.WithUserAsNonRoot())
+
+			out, err := cmd.CombinedOutput()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			golden.CheckOrUpdate(t, out) // TestEscrowDecrypt returns the expected output
+		})
+	}
+}
+
+func TestEscrowSync(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		queued int
+
+		wantErr bool
+	}{
+		"Success_sending_queued_recovery_keys": {queued: 2},
+		"Success_without_queued_recovery_key":  {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			server := escrowtestutils.NewMockEscrowServer(t, ctx)
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+			identityFile := filepath.Join(t.TempDir(), "identity")
+			err = os.WriteFile(identityFile, []byte(id.String()+"\n"), 0o600)
+			is.NoErr(err) // Setup: could not write identity
+
+			// Queued records are stored in the snap common directory.
+			snapCommon := t.TempDir()
+			q := escrow.NewQueue(filepath.Join(snapCommon, "escrow-queue"))
+			for i := range tc.queued {
+				_, err := q.Add(escrow.Record{
+					KeyID:       fmt.Sprintf("key-%d", i),
+					RecoveryKey: "11272-47509-28031-54818-41671-38673-11053-06376",
+					Hostname:    "my-host",
+					Date:        time.Date(2026, 10, 17, 9, 30, i, 0, time.UTC),
+				}, []age.Recipient{id.Recipient()})
+				is.NoErr(err) // Setup: could not queue record
+			}
+
+			args := []string{
+				"escrow", "sync",
+				"--identity", identityFile,
+				"--escrow-server", server.URL,
+				"--escrow-cert", server.CertFile,
+				"--escrow-key", server.KeyFile,
+				"--escrow-ca", server.CAFile,
+			}
+
+			//nolint:gosec // The test intentionally executes the binary built in TestMain.
+			cmd := exec.Command(cmdPath, args...)
+			cmd.Env = append(cmd.Env, testutils.WithRootDir(t.TempDir()), testutils.WithUserAsRoot(), "SNAP_COMMON="+snapCommon)
+
+			out, err := cmd.CombinedOutput()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(len(server.Records()), tc.queued) // Server recorded the queued recovery keys
+
+			pending, err := q.Pending()
+			is.NoErr(err)             // Queue can be read
+			is.Equal(len(pending), 0) // Sent recovery keys are removed from the queue
+
+			golden.CheckOrUpdate(t, strings.ReplaceAll(string(out), server.URL, "<server>")) // TestEscrowSync returns the expected output
+		})
+	}
+}
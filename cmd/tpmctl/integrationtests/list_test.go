@@ -15,11 +15,13 @@ func TestListAll(t *testing.T) {
 
 	tests := map[string]struct {
 		hideHeaders bool
+		format      string
 
 		wantErr bool
 	}{
 		"Success_on_getting_keyslots":                 {},
 		"Success_on_getting_keyslots_without_headers": {hideHeaders: true},
+		"Success_on_getting_keyslots_as_json":         {format: "json"},
 
 		"Error_on_getting_keyslots": {wantErr: true},
 	}
@@ -36,6 +38,9 @@ func TestListAll(t *testing.T) {
 			if tc.hideHeaders {
 				args = append(args, "--no-headers")
 			}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
 
 			root, err := filepath.Abs(testutils.TestPath(t))
 			is.NoErr(err) // Setup: could not find test path
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/testutils"
@@ -56,9 +57,7 @@ func TestMountVolume(t *testing.T) {
 			tc.device = filepath.Join(root, tc.device) // Convert to an absolute path
 
 			if !tc.deviceStatError {
-				f, err := os.Create(tc.device)
-				is.NoErr(err) // Setup: device should exist before mounting
-				defer f.Close()
+				tpmtestutils.SetupFilesystem(is, tc.device, "ext4")
 			}
 
 			if tc.dir == "" {
@@ -75,7 +74,7 @@ func TestMountVolume(t *testing.T) {
 				mapper := tpmtestutils.LuksVolumeName(tc.device)
 				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", filepath.Join(root, "dev", "mapper", mapper), tc.dir)
 			}
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 			tpmtestutils.SetupSysClassBlock(is, root, tc.device, tc.deviceInUse)
 
 			ptmx, tty, err := pty.Open()
@@ -141,7 +140,7 @@ func TestUnmountVolume(t *testing.T) {
 			tc.dir = filepath.Join(root, tc.dir) // Convert to an absolute path
 
 			content := fmt.Sprintf("%s %s ext4 rw 0 0\n", filepath.Join(root, "dev", "mapper", "test"), tc.dir)
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 
 			if tc.emptyDirError {
 				tc.dir = ""
@@ -160,6 +159,95 @@ func TestUnmountVolume(t *testing.T) {
 	}
 }
 
+func TestActivateVolume(t *testing.T) {
+	tests := map[string]struct {
+		deviceInUse bool
+
+		wantErr bool
+	}{
+		"Success on activating volume": {},
+
+		"Error_when_device_is_already_in_use_by_another_tool": {deviceInUse: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+
+			device := filepath.Join(root, "test-device")
+			tpmtestutils.SetupFilesystem(is, device, "ext4")
+			tpmtestutils.SetupSysClassBlock(is, root, device, tc.deviceInUse)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err)
+			defer ptmx.Close()
+			defer tty.Close()
+
+			go func() {
+				fmt.Fprintln(ptmx, "11272-47509-28031-54818-41671-38673-11053-06376")
+			}()
+
+			//nolint:gosec // The test intentionally executes the binary built in TestMain.
+			cmd := exec.Command(cmdPath, "activate-volume", device)
+			cmd.Env = append(cmd.Env, testutils.WithRootDir(root), testutils.WithUserAsRoot())
+			cmd.Stdin = tty
+
+			out, err := cmd.CombinedOutput()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			mapper := filepath.Join(root, "dev", "mapper", tpmtestutils.LuksVolumeName(device))
+			is.True(strings.Contains(string(out), mapper)) // the path of the decrypted device is printed
+		})
+	}
+}
+
+func TestDeactivateVolume(t *testing.T) {
+	tests := map[string]struct {
+		volume string
+
+		wantErr bool
+	}{
+		"Success on deactivating volume": {volume: "test-volume"},
+
+		"Error_when_volume_is_not_active": {volume: "other-volume", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			tpmtestutils.SetupMountInfo(is, root, "")
+
+			mapper := filepath.Join(root, "dev", "mapper", "test-volume")
+			err := os.MkdirAll(filepath.Dir(mapper), 0750)
+			is.NoErr(err) // Setup: mapper directory should exist
+			err = os.WriteFile(mapper, nil, 0600)
+			is.NoErr(err) // Setup: decrypted device should be mapped
+
+			//nolint:gosec // The test intentionally executes the binary built in TestMain.
+			cmd := exec.Command(cmdPath, "deactivate-volume", tc.volume)
+			cmd.Env = append(cmd.Env, testutils.WithRootDir(root), testutils.WithUserAsRoot())
+
+			// no need for checking output here
+			_, err = cmd.CombinedOutput()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+		})
+	}
+}
+
 func TestGetLuksKeyFromRecoveryKey(t *testing.T) {
 	t.Parallel()
 
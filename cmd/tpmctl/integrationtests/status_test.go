@@ -14,9 +14,12 @@ func TestStatus(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
+		format string
+
 		wantErr bool
 	}{
-		"Returns_FDE_status": {},
+		"Returns_FDE_status":         {},
+		"Returns_FDE_status_as_json": {format: "json"},
 
 		"Error_when_getting_FDE_status": {wantErr: true},
 	}
@@ -27,12 +30,15 @@ func TestStatus(t *testing.T) {
 
 			is := is.New(t)
 
-			command := "status"
+			args := []string{"status"}
+			if tc.format != "" {
+				args = append(args, "--format", tc.format)
+			}
 
 			root, err := filepath.Abs(testutils.TestPath(t))
 			is.NoErr(err) // Setup: could not find test path
 
-			cmd := exec.Command(cmdPath, command)
+			cmd := exec.Command(cmdPath, args...)
 			cmd.Env = append(cmd.Env, testutils.WithRootDir(root), testutils.WithUserAsRoot())
 
 			out, err := cmd.CombinedOutput()
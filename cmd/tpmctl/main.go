@@ -3,10 +3,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
 	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	snapdClient "github.com/snapcore/snapd/client"
+)
+
+// Exit codes of snap-tpmctl. They are part of its interface: never change nor reuse them.
+// 2 is reported by the commands themselves, when they made or would make changes.
+const (
+	exitOK                 = 0
+	exitError              = 1
+	exitSnapdUnreachable   = 3
+	exitRootRequired       = 4
+	exitInvalidSecret      = 5
+	exitInvalidRecoveryKey = 6
+	exitKeyslotConflict    = 7
+	exitChangeFailed       = 8
+	exitVolumeInUse        = 9
+	exitNotMounted         = 10
+	exitInterrupted        = 130
 )
 
 type app interface {
@@ -16,14 +38,72 @@ type app interface {
 var mainApp = cmd.New()
 
 func main() {
-	os.Exit(run(context.Background(), mainApp))
+	// Interrupting cancels the context, so that running operations are stopped and the terminal restored.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// Only the first signal is caught: operations which don't watch the context, like prompts, are killed
+	// by the next one as usual.
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	code := run(ctx, mainApp)
+	stop()
+
+	os.Exit(code)
 }
 
 func run(ctx context.Context, a app) int {
 	if err := a.Run(ctx); err != nil {
-		log.Error(ctx, "%v", err)
-		return 1
+		// Outcomes reported through the exit status are not failures.
+		if status, ok := errors.AsType[cmd.ExitStatusError](err); ok {
+			return int(status)
+		}
+
+		// Explain the known snapd errors, and only show their raw cause in verbose mode.
+		if msg, ok := snapd.Explain(err); ok {
+			log.Error(ctx, "%s", msg)
+			log.Info(ctx, "Cause: %v", err)
+		} else {
+			log.Error(ctx, "%v", err)
+		}
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+// exitCode returns the exit code telling scripts why the command failed with err.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, snapd.ErrUnreachable):
+		return exitSnapdUnreachable
+	case errors.Is(err, cmd.ErrRootRequired):
+		return exitRootRequired
+	case errors.Is(err, tpm.ErrInvalidRecoveryKey):
+		return exitInvalidRecoveryKey
+	case errors.Is(err, tpm.ErrAlreadyMapped), errors.Is(err, tpm.ErrAlreadyMounted):
+		return exitVolumeInUse
+	case errors.Is(err, tpm.ErrNotMounted), errors.Is(err, tpm.ErrNotActive):
+		return exitNotMounted
+	case errors.Is(err, snapd.ErrChangeAborted), errors.Is(err, snapd.ErrChangeDetached), errors.Is(err, context.Canceled):
+		return exitInterrupted
+	}
+
+	if snapdErr, ok := errors.AsType[*snapd.Error](err); ok {
+		switch snapdErr.Kind {
+		case snapdClient.ErrorKindInvalidPIN, snapdClient.ErrorKindInvalidPassphrase:
+			return exitInvalidSecret
+		case snapdClient.ErrorKindInvalidRecoveryKey:
+			return exitInvalidRecoveryKey
+		case snapdClient.ErrorKindKeyslotsNotFound, snapdClient.ErrorKindKeyslotsAlreadyExists:
+			return exitKeyslotConflict
+		}
+
+		// Like a wrong current PIN or passphrase.
+		if snapdErr.ChangeID != "" {
+			return exitChangeFailed
+		}
 	}
 
-	return 0
+	return exitError
 }
@@ -3,11 +3,18 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"strings"
 	"testing"
 
+	"github.com/canonical/snap-tpmctl/cmd/tpmctl/cmd"
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
 	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/matryer/is"
+	snapdClient "github.com/snapcore/snapd/client"
 )
 
 type mockApp struct{ err error }
@@ -20,13 +27,39 @@ func TestRun(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		app mockApp
+		app     mockApp
+		verbose bool
 
-		want      int
-		wantInLog string
+		want         int
+		wantInLog    string
+		wantNotInLog string
+		wantNoLog    bool
 	}{
 		"Returns 0 on success": {app: mockApp{err: nil}, want: 0},
 		"Returns 1 on error":   {app: mockApp{err: errors.New("desired error")}, want: 1, wantInLog: "desired error"},
+
+		"Returns exit status without logging it": {app: mockApp{err: cmd.ExitStatusError(2)}, want: 2, wantNoLog: true},
+
+		"Returns 3 when snapd is unreachable":          {app: mockApp{err: fmt.Errorf("failed to add PIN: %w", snapd.ErrUnreachable)}, want: 3},
+		"Returns 4 when root is required":              {app: mockApp{err: cmd.ErrRootRequired}, want: 4},
+		"Returns 5 on invalid PIN":                     {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindInvalidPIN, "")}, want: 5},
+		"Returns 5 on invalid passphrase":              {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindInvalidPassphrase, "")}, want: 5},
+		"Returns 6 on recovery key refused by snapd":   {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindInvalidRecoveryKey, "")}, want: 6},
+		"Returns 6 on malformed recovery key":          {app: mockApp{err: fmt.Errorf("failed to get LUKS key: %w", tpm.ErrInvalidRecoveryKey)}, want: 6},
+		"Returns 7 when keyslots are not found":        {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindKeyslotsNotFound, "")}, want: 7},
+		"Returns 7 when keyslots already exist":        {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindKeyslotsAlreadyExists, "")}, want: 7},
+		"Returns 8 when the snapd change failed":       {app: mockApp{err: wrapSnapdError("", "42")}, want: 8},
+		"Returns 9 when the device is already mapped":  {app: mockApp{err: fmt.Errorf("unable to activate device: %w", tpm.ErrAlreadyMapped)}, want: 9},
+		"Returns 9 when the volume is already mounted": {app: mockApp{err: fmt.Errorf("unable to activate volume: %w", tpm.ErrAlreadyMounted)}, want: 9},
+		"Returns 10 when the path is not mounted":      {app: mockApp{err: tpm.ErrNotMounted}, want: 10},
+		"Returns 10 when the volume is not active":     {app: mockApp{err: fmt.Errorf("%w: \"vol\" does not exist", tpm.ErrNotActive)}, want: 10},
+		"Returns 130 when the change was aborted":      {app: mockApp{err: fmt.Errorf("failed to add PIN: %w", snapd.ErrChangeAborted)}, want: 130},
+		"Returns 130 when the change was detached":     {app: mockApp{err: snapd.ErrChangeDetached}, want: 130},
+		"Returns 1 on other snapd errors":              {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindBadQuery, "")}, want: 1},
+
+		"Logs hint instead of known snapd errors":    {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindKeyslotsAlreadyExists, "")}, want: 7, wantInLog: "failed to add PIN: a key with this name already exists", wantNotInLog: "snapd error"},
+		"Logs cause of known snapd errors verbosely": {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindKeyslotsAlreadyExists, "")}, verbose: true, want: 7, wantInLog: "Cause: failed to add PIN: snapd error: error (keyslots-already-exist)"},
+		"Logs unknown snapd errors as is":            {app: mockApp{err: wrapSnapdError(snapdClient.ErrorKindBadQuery, "")}, want: 1, wantInLog: "failed to add PIN: snapd error: error (bad-query)"},
 	}
 
 	for name, tc := range tests {
@@ -34,6 +67,9 @@ func TestRun(t *testing.T) {
 			t.Parallel()
 			is := is.NewRelaxed(t)
 			ctx, logs := testutils.TestLoggerWithBuffer(t)
+			if tc.verbose {
+				log.SetLoggerLevelInContext(ctx, slog.LevelInfo)
+			}
 
 			got := run(ctx, tc.app)
 			is.Equal(tc.want, got) // Return value does not match exit code
@@ -41,6 +77,17 @@ func TestRun(t *testing.T) {
 			if tc.wantInLog != "" {
 				is.True(strings.Contains(logs.String(), tc.wantInLog)) // Log does not contain expected message
 			}
+			if tc.wantNotInLog != "" {
+				is.True(!strings.Contains(logs.String(), tc.wantNotInLog)) // Log contains unexpected message
+			}
+			if tc.wantNoLog {
+				is.Equal(logs.String(), "") // Log is empty
+			}
 		})
 	}
 }
+
+// wrapSnapdError returns a snapd error of the given kind, or of a failed change, as wrapped by the tpm package.
+func wrapSnapdError(kind snapdClient.ErrorKind, changeID string) error {
+	return fmt.Errorf("failed to add PIN: %w", &snapd.Error{Message: "error", Kind: kind, ChangeID: changeID})
+}
@@ -0,0 +1,168 @@
+package escrow
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	// defaultAttempts is how many times a record is sent before giving up.
+	defaultAttempts = 3
+
+	// defaultRetryDelay is the delay before the first retry, doubled after each failed attempt.
+	defaultRetryDelay = time.Second
+
+	// requestTimeout is how long a single attempt to send a record can take.
+	requestTimeout = 30 * time.Second
+)
+
+// ErrUnavailable is returned when the escrow server could not be reached, failed to handle the record or limited
+// the requests, which may succeed later.
+var ErrUnavailable = errors.New("escrow server unavailable")
+
+// Client sends records to a key escrow server over mutually authenticated TLS.
+type Client struct {
+	clientOptions
+
+	endpoint string
+	http     *http.Client
+}
+
+type clientOptions struct {
+	attempts   int
+	retryDelay time.Duration
+}
+
+// ClientOption is a function that configures a Client.
+type ClientOption func(*clientOptions)
+
+// NewClient creates a client for the escrow server endpoint, an https URL records are POSTed to.
+// The client authenticates with the certificate and private key of certFile and keyFile. The server
+// certificate is verified against the authorities of caFile or, when empty, against the system ones.
+func NewClient(endpoint, certFile, keyFile, caFile string, args ...ClientOption) (*Client, error) {
+	o := clientOptions{
+		attempts:   defaultAttempts,
+		retryDelay: defaultRetryDelay,
+	}
+	for _, f := range args {
+		f(&o)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid escrow server %q: must be an https URL", endpoint)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid escrow client certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid escrow server CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid escrow server CA: no PEM certificate found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &Client{
+		clientOptions: o,
+		endpoint:      endpoint,
+		http: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// Endpoint returns the URL of the escrow server.
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+// Send posts the record to the escrow server. Attempts failing because the server can't be reached
+// or is unavailable are retried with an exponential backoff, while rejections are returned right away.
+// Once all attempts failed, the error wraps ErrUnavailable if the server could not take the record.
+func (c *Client) Send(ctx context.Context, r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode escrow record: %w", err)
+	}
+
+	delay := c.retryDelay
+	for attempt := 1; ; attempt++ {
+		err = c.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		var rejected *rejectedError
+		if errors.As(err, &rejected) || attempt >= c.attempts {
+			return fmt.Errorf("failed to escrow recovery key to %s: %w", c.endpoint, err)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to escrow recovery key to %s: %w", c.endpoint, ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+// rejectedError is returned when the escrow server refused a record, which would be refused again.
+type rejectedError struct {
+	status string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("rejected by server: %s", e.status)
+}
+
+// post makes a single attempt to send the encoded record.
+func (c *Client) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s", ErrUnavailable, resp.Status)
+	default:
+		return &rejectedError{status: resp.Status}
+	}
+}
@@ -0,0 +1,144 @@
+package escrow_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	escrowtestutils "github.com/canonical/snap-tpmctl/internal/escrow/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		endpoint    string
+		noCert      bool
+		caContent   string
+		missingCA   bool
+		useSystemCA bool
+
+		wantErr bool
+	}{
+		"Creates_client":                 {},
+		"Creates_client_with_system_CAs": {useSystemCA: true},
+
+		"Error_on_http_endpoint":         {endpoint: "http://127.0.0.1/v1/recovery-keys", wantErr: true},
+		"Error_on_endpoint_without_host": {endpoint: "https:///v1/recovery-keys", wantErr: true},
+		"Error_on_invalid_endpoint":      {endpoint: "://", wantErr: true},
+		"Error_on_missing_certificate":   {noCert: true, wantErr: true},
+		"Error_on_missing_CA":            {missingCA: true, wantErr: true},
+		"Error_on_invalid_CA":            {caContent: "not a certificate", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			s := escrowtestutils.NewMockEscrowServer(t, ctx)
+
+			endpoint := s.URL
+			if tc.endpoint != "" {
+				endpoint = tc.endpoint
+			}
+			certFile := s.CertFile
+			if tc.noCert {
+				certFile = filepath.Join(t.TempDir(), "missing.pem")
+			}
+			caFile := s.CAFile
+			switch {
+			case tc.useSystemCA:
+				caFile = ""
+			case tc.missingCA:
+				caFile = filepath.Join(t.TempDir(), "missing.pem")
+			case tc.caContent != "":
+				caFile = filepath.Join(t.TempDir(), "ca.pem")
+				err := os.WriteFile(caFile, []byte(tc.caContent), 0o600)
+				is.NoErr(err) // Setup: could not write CA file
+			}
+
+			c, err := escrow.NewClient(endpoint, certFile, s.KeyFile, caFile)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(c.Endpoint(), endpoint) // Client sends records to the endpoint
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		failures      int
+		failureStatus int
+		untrusted     bool
+		unreachable   bool
+		cancelled     bool
+
+		wantRequests    int
+		wantUnavailable bool
+		wantErr         bool
+	}{
+		"Sends_record":                         {wantRequests: 1},
+		"Sends_record_after_server_errors":     {failures: 2, failureStatus: http.StatusServiceUnavailable, wantRequests: 3},
+		"Sends_record_after_too_many_requests": {failures: 1, failureStatus: http.StatusTooManyRequests, wantRequests: 2},
+
+		"Error_when_server_keeps_failing":           {failures: 3, failureStatus: http.StatusInternalServerError, wantRequests: 3, wantUnavailable: true, wantErr: true},
+		"Error_when_server_keeps_limiting_requests": {failures: 3, failureStatus: http.StatusTooManyRequests, wantRequests: 3, wantUnavailable: true, wantErr: true},
+		"Error_without_retry_when_rejected":         {failures: 1, failureStatus: http.StatusBadRequest, wantRequests: 1, wantErr: true},
+		"Error_with_untrusted_client_certificate":   {untrusted: true, wantUnavailable: true, wantErr: true},
+		"Error_when_server_is_unreachable":          {unreachable: true, wantUnavailable: true, wantErr: true},
+		"Error_when_context_is_cancelled":           {cancelled: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			s := escrowtestutils.NewMockEscrowServer(t, ctx, escrowtestutils.WithFailures(tc.failures, tc.failureStatus))
+
+			certFile, keyFile := s.CertFile, s.KeyFile
+			if tc.untrusted {
+				certFile, keyFile = escrowtestutils.NewUntrustedCertificate(t)
+			}
+			if tc.unreachable {
+				s.Close()
+			}
+			if tc.cancelled {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+
+			c, err := escrow.NewClient(s.URL, certFile, keyFile, s.CAFile, escrowtestutils.WithRetryDelay(time.Millisecond))
+			is.NoErr(err) // Setup: could not create escrow client
+
+			err = c.Send(ctx, newRecord())
+			if !tc.unreachable {
+				is.Equal(s.Requests(), tc.wantRequests) // Server receives the expected number of attempts
+			}
+			if testutils.CheckError(is, err, tc.wantErr) {
+				is.Equal(errors.Is(err, escrow.ErrUnavailable), tc.wantUnavailable) // Error tells whether the server may take the record later
+				is.Equal(len(s.Records()), 0)                                       // Server did not record anything
+				is.True(!strings.Contains(err.Error(), recoveryKey))                // Recovery key is not leaked in errors
+				return
+			}
+
+			is.Equal(s.Records(), []escrow.Record{newRecord()}) // Server received the record
+		})
+	}
+}
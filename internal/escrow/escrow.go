@@ -0,0 +1,146 @@
+// Package escrow seals recovery keys for offline storage, encrypted to the age X25519 public keys of their custodians,
+// or sends them to a key escrow server. Sealed files never hold the plaintext: it is only streamed through the
+// encryption in memory.
+package escrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// fileExtension is the extension of the escrowed recovery key files.
+const fileExtension = ".age"
+
+// Record is an escrowed recovery key along with what is needed to find the machine it unlocks.
+type Record struct {
+	KeyID          string    `json:"key-id" yaml:"key-id"`
+	RecoveryKey    string    `json:"recovery-key" yaml:"recovery-key"`
+	ContainerRoles []string  `json:"container-roles" yaml:"container-roles"`
+	Hostname       string    `json:"hostname" yaml:"hostname"`
+	MachineID      string    `json:"machine-id" yaml:"machine-id"`
+	Date           time.Time `json:"date" yaml:"date"`
+}
+
+// ParseRecipients parses age X25519 public keys, like "age1...".
+func ParseRecipients(keys []string) ([]age.Recipient, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no escrow recipient provided")
+	}
+
+	var recipients []age.Recipient
+	for _, k := range keys {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(k))
+		if err != nil {
+			return nil, fmt.Errorf("invalid escrow recipient %q: %w", k, err)
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, nil
+}
+
+// Seal writes the record to w as an ASCII armored age file, which only the identities of the recipients can open.
+func Seal(w io.Writer, r Record, recipients []age.Recipient) error {
+	if len(recipients) == 0 {
+		return errors.New("no escrow recipient provided")
+	}
+
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt escrow record: %w", err)
+	}
+
+	if err := json.NewEncoder(ew).Encode(r); err != nil {
+		return fmt.Errorf("failed to encrypt escrow record: %w", err)
+	}
+	if err := ew.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt escrow record: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt escrow record: %w", err)
+	}
+
+	return nil
+}
+
+// Open decrypts an escrowed record with the age identities read from identities, in the age identity file format.
+func Open(r io.Reader, identities io.Reader) (record Record, err error) {
+	ids, err := ParseIdentities(identities)
+	if err != nil {
+		return record, err
+	}
+
+	return open(r, ids)
+}
+
+// ParseIdentities parses age identities, in the age identity file format.
+func ParseIdentities(identities io.Reader) ([]age.Identity, error) {
+	ids, err := age.ParseIdentities(identities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid escrow identity: %w", err)
+	}
+
+	return ids, nil
+}
+
+// open decrypts an escrowed record with the parsed identities.
+func open(r io.Reader, ids []age.Identity) (record Record, err error) {
+	dr, err := age.Decrypt(armor.NewReader(r), ids...)
+	if err != nil {
+		return record, fmt.Errorf("failed to decrypt escrow record: %w", err)
+	}
+
+	if err := json.NewDecoder(dr).Decode(&record); err != nil {
+		return record, fmt.Errorf("failed to decrypt escrow record: %w", err)
+	}
+
+	return record, nil
+}
+
+// WriteFile seals the record in a new file of dir and returns its path.
+func WriteFile(dir string, r Record, recipients []age.Recipient) (string, error) {
+	path := filepath.Join(dir, recordFileName(r, fileExtension))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create escrow file: %w", err)
+	}
+
+	err = Seal(f, r, recipients)
+	if e := f.Close(); e != nil && err == nil {
+		err = fmt.Errorf("failed to close escrow file: %w", e)
+	}
+	if err != nil {
+		// Don't leave a partial record behind.
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// recordFileName returns the name of a file holding the record, identifying its machine, key and date.
+func recordFileName(r Record, ext string) string {
+	return fmt.Sprintf("%s-%s-%s%s", sanitize(r.Hostname), sanitize(r.KeyID), r.Date.UTC().Format("20060102T150405Z"), ext)
+}
+
+// sanitize keeps s usable as part of a file name.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
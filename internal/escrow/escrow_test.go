@@ -0,0 +1,199 @@
+package escrow_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+const recoveryKey = "11272-47509-28031-54818-41671-38673-11053-06376"
+
+func newRecord() escrow.Record {
+	return escrow.Record{
+		KeyID:          "my-key",
+		RecoveryKey:    recoveryKey,
+		ContainerRoles: []string{"system-data", "system-save"},
+		Hostname:       "my-host",
+		MachineID:      "0123456789abcdef0123456789abcdef",
+		Date:           time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	t.Parallel()
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Setup: could not generate identity: %v", err)
+	}
+
+	tests := map[string]struct {
+		keys []string
+
+		wantCount int
+		wantErr   bool
+	}{
+		"Parses_one_recipient":             {keys: []string{id.Recipient().String()}, wantCount: 1},
+		"Parses_recipient_with_whitespace": {keys: []string{" " + id.Recipient().String() + "\n"}, wantCount: 1},
+		"Parses_several_recipients":        {keys: []string{id.Recipient().String(), id.Recipient().String()}, wantCount: 2},
+
+		"Error_without_recipient":        {wantErr: true},
+		"Error_on_invalid_recipient":     {keys: []string{"not-a-key"}, wantErr: true},
+		"Error_on_private_key_recipient": {keys: []string{id.String()}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			got, err := escrow.ParseRecipients(tc.keys)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(len(got), tc.wantCount) // ParseRecipients returns one recipient per key
+		})
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		recipients      int
+		openWithIndex   int
+		openWithOther   bool
+		invalidIdentity bool
+		corrupted       bool
+
+		wantErr bool
+	}{
+		"Opens_with_the_recipient_identity":      {recipients: 1},
+		"Opens_with_any_of_several_recipients":   {recipients: 2, openWithIndex: 1},
+		"Error_opening_with_another_identity":    {recipients: 1, openWithOther: true, wantErr: true},
+		"Error_opening_with_an_invalid_identity": {recipients: 1, invalidIdentity: true, wantErr: true},
+		"Error_opening_a_corrupted_record":       {recipients: 1, corrupted: true, wantErr: true},
+		"Error_sealing_without_recipient":        {wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			var ids []*age.X25519Identity
+			var recipients []age.Recipient
+			for range tc.recipients {
+				id, err := age.GenerateX25519Identity()
+				is.NoErr(err) // Setup: could not generate identity
+				ids = append(ids, id)
+				recipients = append(recipients, id.Recipient())
+			}
+
+			var sealed strings.Builder
+			err := escrow.Seal(&sealed, newRecord(), recipients)
+			if len(recipients) == 0 {
+				is.True(err != nil) // Seal fails without recipient
+				return
+			}
+			is.NoErr(err) // Seal succeeds
+
+			is.True(strings.HasPrefix(sealed.String(), "-----BEGIN AGE ENCRYPTED FILE-----")) // Record is ASCII armored
+			is.True(!strings.Contains(sealed.String(), recoveryKey))                          // Recovery key is not in plaintext
+
+			identity := ids[tc.openWithIndex].String()
+			if tc.openWithOther {
+				other, err := age.GenerateX25519Identity()
+				is.NoErr(err) // Setup: could not generate identity
+				identity = other.String()
+			}
+			if tc.invalidIdentity {
+				identity = "not-an-identity"
+			}
+
+			data := sealed.String()
+			if tc.corrupted {
+				data = strings.Replace(data, "\n", "\nAAAA", 2)
+			}
+
+			got, err := escrow.Open(strings.NewReader(data), strings.NewReader(identity))
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, newRecord()) // Open returns the sealed record
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		keyID      string
+		missingDir bool
+		exists     bool
+
+		wantName string
+		wantErr  bool
+	}{
+		"Writes_record_in_directory":            {wantName: "my-host-my-key-20261017T093000Z.age"},
+		"Writes_record_with_sanitized_key_name": {keyID: "../my key", wantName: "my-host-.._my_key-20261017T093000Z.age"},
+
+		"Error_when_directory_does_not_exist": {missingDir: true, wantErr: true},
+		"Error_when_record_already_exists":    {exists: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+
+			dir := t.TempDir()
+			if tc.missingDir {
+				dir = filepath.Join(dir, "missing")
+			}
+
+			r := newRecord()
+			if tc.keyID != "" {
+				r.KeyID = tc.keyID
+			}
+
+			if tc.exists {
+				_, err := escrow.WriteFile(dir, r, []age.Recipient{id.Recipient()})
+				is.NoErr(err) // Setup: could not write first record
+			}
+
+			path, err := escrow.WriteFile(dir, r, []age.Recipient{id.Recipient()})
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(filepath.Base(path), tc.wantName) // Record file is named after the host, key and date
+			is.Equal(filepath.Dir(path), dir)          // Record file is in the escrow directory
+
+			fi, err := os.Stat(path)
+			is.NoErr(err)                                  // Record file exists
+			is.Equal(fi.Mode().Perm(), os.FileMode(0o600)) // Record file is only readable by its owner
+
+			f, err := os.Open(path)
+			is.NoErr(err) // Setup: could not open record file
+			defer f.Close()
+
+			got, err := escrow.Open(f, strings.NewReader(id.String()))
+			is.NoErr(err)    // Record file can be opened
+			is.Equal(got, r) // Record file holds the record
+		})
+	}
+}
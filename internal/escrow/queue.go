@@ -0,0 +1,126 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Queue keeps the records which could not be sent to the escrow server, until they are synced.
+// Queued records are sealed like escrowed files, so that the recovery key is never left in clear on disk:
+// syncing them needs the identity of one of their recipients.
+type Queue struct {
+	dir string
+}
+
+// NewQueue returns the queue of records stored in dir.
+func NewQueue(dir string) Queue {
+	return Queue{dir: dir}
+}
+
+// Dir returns the directory where the records are queued.
+func (q Queue) Dir() string {
+	return q.dir
+}
+
+// Add seals the record to the recipients in the queue and returns the path where it is stored.
+func (q Queue) Add(r Record, recipients []age.Recipient) (string, error) {
+	if len(recipients) == 0 {
+		return "", errors.New("failed to queue escrow record: no escrow recipient to seal it to")
+	}
+
+	if err := os.MkdirAll(q.dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create escrow queue: %w", err)
+	}
+
+	path, err := WriteFile(q.dir, r, recipients)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue escrow record: %w", err)
+	}
+
+	return path, nil
+}
+
+// Pending returns the paths of the queued records, oldest first.
+func (q Queue) Pending() ([]string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read escrow queue: %w", err)
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasSuffix(e.Name(), fileExtension) {
+			paths = append(paths, filepath.Join(q.dir, e.Name()))
+		}
+	}
+
+	// Names end with the record date, so that they sort in the order the records were queued.
+	slices.SortStableFunc(paths, func(a, b string) int {
+		return strings.Compare(recordFileDate(a), recordFileDate(b))
+	})
+
+	return paths, nil
+}
+
+// Sync opens the queued records with the identities, sends them to the escrow server and removes the ones which were sent.
+// It returns how many records were sent, and the errors of the ones which are still queued.
+func (q Queue) Sync(ctx context.Context, c *Client, identities []age.Identity) (sent int, err error) {
+	paths, err := q.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if err := sendQueued(ctx, c, path, identities); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// sendQueued sends the record queued at path and removes it once it was sent.
+func sendQueued(ctx context.Context, c *Client, path string, identities []age.Identity) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read queued escrow record: %w", err)
+	}
+	defer f.Close()
+
+	r, err := open(f, identities)
+	if err != nil {
+		return fmt.Errorf("invalid queued escrow record: %w", err)
+	}
+
+	if err := c.Send(ctx, r); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove sent escrow record from queue: %w", err)
+	}
+
+	return nil
+}
+
+// recordFileDate returns the date part of a record file name, which sorts chronologically.
+func recordFileDate(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if i := strings.LastIndex(name, "-"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,221 @@
+package escrow_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	escrowtestutils "github.com/canonical/snap-tpmctl/internal/escrow/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestQueueAdd(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		dirIsFile    bool
+		exists       bool
+		noRecipients bool
+
+		wantName string
+		wantErr  bool
+	}{
+		"Queues_sealed_record_in_new_directory": {wantName: "my-host-my-key-20261017T093000Z.age"},
+
+		"Error_when_directory_is_a_file":   {dirIsFile: true, wantErr: true},
+		"Error_when_record_already_exists": {exists: true, wantErr: true},
+		"Error_without_recipient":          {noRecipients: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			dir := filepath.Join(t.TempDir(), "queue")
+			if tc.dirIsFile {
+				err := os.WriteFile(dir, nil, 0o600)
+				is.NoErr(err) // Setup: could not create file
+			}
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+			recipients := []age.Recipient{id.Recipient()}
+			if tc.noRecipients {
+				recipients = nil
+			}
+
+			q := escrow.NewQueue(dir)
+			if tc.exists {
+				_, err := q.Add(newRecord(), recipients)
+				is.NoErr(err) // Setup: could not queue first record
+			}
+
+			path, err := q.Add(newRecord(), recipients)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				if tc.noRecipients {
+					_, err := os.Stat(dir)
+					is.True(os.IsNotExist(err)) // Nothing is queued without recipient
+				}
+				return
+			}
+
+			is.Equal(filepath.Base(path), tc.wantName) // Queued record is named after the host, key and date
+
+			fi, err := os.Stat(dir)
+			is.NoErr(err)                                  // Queue directory exists
+			is.Equal(fi.Mode().Perm(), os.FileMode(0o700)) // Queue directory is only accessible by its owner
+
+			fi, err = os.Stat(path)
+			is.NoErr(err)                                  // Queued record exists
+			is.Equal(fi.Mode().Perm(), os.FileMode(0o600)) // Queued record is only readable by its owner
+
+			pending, err := q.Pending()
+			is.NoErr(err)                     // Pending succeeds
+			is.Equal(pending, []string{path}) // Queued record is pending
+
+			data, err := os.ReadFile(path)
+			is.NoErr(err)                                         // Queued record can be read
+			is.True(!strings.Contains(string(data), recoveryKey)) // Recovery key is not in plaintext
+
+			got, err := escrow.Open(strings.NewReader(string(data)), strings.NewReader(id.String()))
+			is.NoErr(err)              // Queued record can be opened by its recipient
+			is.Equal(got, newRecord()) // Queued record holds the record
+		})
+	}
+}
+
+func TestQueuePending(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		records    int
+		missingDir bool
+		otherFile  bool
+
+		wantErr bool
+	}{
+		"Returns_records_oldest_first":  {records: 3},
+		"Returns_nothing_without_queue": {missingDir: true},
+		"Ignores_other_files":           {records: 1, otherFile: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+
+			dir := filepath.Join(t.TempDir(), "queue")
+			q := escrow.NewQueue(dir)
+			if !tc.missingDir {
+				err := os.MkdirAll(dir, 0o700)
+				is.NoErr(err) // Setup: could not create queue directory
+			}
+			if tc.otherFile {
+				err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600)
+				is.NoErr(err) // Setup: could not create file
+			}
+
+			var want []string
+			for i := range tc.records {
+				r := newRecord()
+				// Key names don't sort the same way as the dates.
+				r.KeyID = string(rune('z' - i))
+				r.Date = r.Date.Add(time.Duration(i) * time.Minute)
+				path, err := q.Add(r, []age.Recipient{id.Recipient()})
+				is.NoErr(err) // Setup: could not queue record
+				want = append(want, path)
+			}
+
+			got, err := q.Pending()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, want) // Pending returns the queued records, oldest first
+		})
+	}
+}
+
+func TestQueueSync(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		records       int
+		corrupted     bool
+		otherIdentity bool
+		failures      int
+		unreachable   bool
+
+		wantSent    int
+		wantPending int
+		wantErr     bool
+	}{
+		"Sends_all_queued_records": {records: 2, wantSent: 2},
+		"Sends_nothing_when_empty": {},
+
+		"Error_keeps_records_when_server_is_unreachable": {records: 2, unreachable: true, wantPending: 2, wantErr: true},
+		"Error_keeps_records_rejected_by_server":         {records: 2, failures: 1, wantSent: 1, wantPending: 1, wantErr: true},
+		"Error_keeps_corrupted_records":                  {records: 1, corrupted: true, wantPending: 1, wantErr: true},
+		"Error_keeps_records_of_other_recipients":        {records: 1, otherIdentity: true, wantPending: 1, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx, _ := testutils.TestLoggerWithBuffer(t)
+
+			s := escrowtestutils.NewMockEscrowServer(t, ctx, escrowtestutils.WithFailures(tc.failures, http.StatusForbidden))
+			if tc.unreachable {
+				s.Close()
+			}
+
+			id, err := age.GenerateX25519Identity()
+			is.NoErr(err) // Setup: could not generate identity
+
+			q := escrow.NewQueue(t.TempDir())
+			for i := range tc.records {
+				r := newRecord()
+				r.Date = r.Date.Add(time.Duration(i) * time.Minute)
+				path, err := q.Add(r, []age.Recipient{id.Recipient()})
+				is.NoErr(err) // Setup: could not queue record
+				if tc.corrupted {
+					err := os.WriteFile(path, []byte("not sealed"), 0o600)
+					is.NoErr(err) // Setup: could not corrupt record
+				}
+			}
+
+			c, err := escrow.NewClient(s.URL, s.CertFile, s.KeyFile, s.CAFile, escrowtestutils.WithRetryDelay(time.Millisecond))
+			is.NoErr(err) // Setup: could not create escrow client
+
+			identity := id
+			if tc.otherIdentity {
+				identity, err = age.GenerateX25519Identity()
+				is.NoErr(err) // Setup: could not generate identity
+			}
+
+			sent, err := q.Sync(ctx, c, []age.Identity{identity})
+			testutils.CheckError(is, err, tc.wantErr)
+
+			is.Equal(sent, tc.wantSent) // Sync returns how many records were sent
+
+			pending, err := q.Pending()
+			is.NoErr(err)                          // Pending succeeds
+			is.Equal(len(pending), tc.wantPending) // Records which were not sent are still queued
+
+			if !tc.unreachable {
+				is.Equal(len(s.Records()), tc.wantSent) // Server received the sent records
+			}
+		})
+	}
+}
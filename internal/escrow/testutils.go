@@ -0,0 +1,16 @@
+//nolint:unused // helper functions used only in tests
+package escrow
+
+import (
+	"time"
+
+	"github.com/canonical/snap-tpmctl/internal/testutils/testsdetection"
+)
+
+// withRetryDelay allows you to specify a custom delay before retrying to send a record for testing purposes.
+func withRetryDelay(d time.Duration) ClientOption {
+	testsdetection.MustBeTesting()
+	return func(o *clientOptions) {
+		o.retryDelay = d
+	}
+}
@@ -0,0 +1,248 @@
+// Package escrowtestutils exports testing functionalities used by other packages.
+//
+//nolint:revive // this package is used only in tests
+package escrowtestutils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	_ "unsafe" // Required for go:linkname directives
+
+	"github.com/canonical/snap-tpmctl/internal/escrow"
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/testutils/testsdetection"
+	"github.com/matryer/is"
+)
+
+func init() {
+	testsdetection.MustBeTesting()
+}
+
+// WithRetryDelay is an option that configures the escrow client to wait d before retrying to send a record.
+//
+//go:linkname WithRetryDelay github.com/canonical/snap-tpmctl/internal/escrow.withRetryDelay
+func WithRetryDelay(d time.Duration) escrow.ClientOption
+
+// MockEscrowServer is a key escrow server, only accepting clients with a certificate of its own authority.
+type MockEscrowServer struct {
+	// URL is the endpoint records are sent to.
+	URL string
+	// CAFile is the authority of the server certificate.
+	CAFile string
+	// CertFile and KeyFile are a client certificate accepted by the server.
+	CertFile string
+	KeyFile  string
+
+	mu       sync.Mutex
+	requests int
+	records  []escrow.Record
+
+	server *httptest.Server
+}
+
+type options struct {
+	failures int
+	status   int
+}
+
+// Option is a function that configures a MockEscrowServer.
+type Option func(*options)
+
+// WithFailures is an option that makes the server answer the first n requests with the HTTP status code.
+func WithFailures(n, status int) Option {
+	return func(o *options) {
+		o.failures = n
+		o.status = status
+	}
+}
+
+// NewMockEscrowServer starts a key escrow server over mutually authenticated TLS, which records what it receives.
+// Its authority and a client certificate are written in a temporary directory.
+func NewMockEscrowServer(t *testing.T, ctx context.Context, args ...Option) *MockEscrowServer {
+	t.Helper()
+
+	o := options{}
+	for _, f := range args {
+		f(&o)
+	}
+
+	dir := t.TempDir()
+	m := MockEscrowServer{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client.key"),
+	}
+
+	ca, caKey := newCertificate(t, "Escrow CA", nil, nil, func(tmpl *x509.Certificate) {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	})
+	writeCertificate(t, m.CAFile, ca, nil)
+
+	server, serverKey := newCertificate(t, "Escrow server", ca, caKey, func(tmpl *x509.Certificate) {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	})
+
+	client, clientKey := newCertificate(t, "Escrow client", ca, caKey, func(tmpl *x509.Certificate) {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	writeCertificate(t, m.CertFile, client, nil)
+	writeCertificate(t, m.KeyFile, nil, clientKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Debug(ctx, "Escrow server received request: %v %v", r.Method, r.URL.Path)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.requests++
+		if m.requests <= o.failures {
+			http.Error(w, http.StatusText(o.status), o.status)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var record escrow.Record
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.records = append(m.records, record)
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	// Rejected client certificates are expected in tests: don't log them.
+	ts.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	m.URL = ts.URL + "/v1/recovery-keys"
+	m.server = ts
+
+	return &m
+}
+
+// Close stops the server, so that it can't be reached anymore.
+func (m *MockEscrowServer) Close() {
+	m.server.Close()
+}
+
+// Requests returns how many requests the server received.
+func (m *MockEscrowServer) Requests() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.requests
+}
+
+// Records returns the records the server accepted.
+func (m *MockEscrowServer) Records() []escrow.Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]escrow.Record(nil), m.records...)
+}
+
+// NewUntrustedCertificate writes a self-signed client certificate, which escrow servers don't accept,
+// in a temporary directory and returns its certificate and key file paths.
+func NewUntrustedCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "untrusted.pem")
+	keyFile = filepath.Join(dir, "untrusted.key")
+
+	cert, key := newCertificate(t, "Untrusted client", nil, nil, func(tmpl *x509.Certificate) {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+	writeCertificate(t, certFile, cert, nil)
+	writeCertificate(t, keyFile, nil, key)
+
+	return certFile, keyFile
+}
+
+// newCertificate creates a certificate signed by parent, or self-signed if parent is nil.
+func newCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, customize func(*x509.Certificate)) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	is := is.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	is.NoErr(err) // Setup: could not generate certificate key
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	is.NoErr(err) // Setup: could not generate certificate serial number
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	customize(tmpl)
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	is.NoErr(err) // Setup: could not create certificate
+
+	cert, err := x509.ParseCertificate(der)
+	is.NoErr(err) // Setup: could not parse certificate
+
+	return cert, key
+}
+
+// writeCertificate writes the certificate or the private key to path, in PEM format.
+func writeCertificate(t *testing.T, path string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	t.Helper()
+	is := is.New(t)
+
+	block := &pem.Block{}
+	if cert != nil {
+		block.Type = "CERTIFICATE"
+		block.Bytes = cert.Raw
+	} else {
+		der, err := x509.MarshalECPrivateKey(key)
+		is.NoErr(err) // Setup: could not marshal private key
+		block.Type = "EC PRIVATE KEY"
+		block.Bytes = der
+	}
+
+	err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+	is.NoErr(err) // Setup: could not write certificate
+}
@@ -0,0 +1,244 @@
+// Package fdestate describes the desired FDE state of a machine and plans the changes reconciling the system with it.
+package fdestate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported authentication modes of the platform keys.
+const (
+	AuthNone       = "none"
+	AuthPIN        = "pin"
+	AuthPassphrase = "passphrase"
+)
+
+// fdeEnabled is the FDE status of a system whose volumes are encrypted.
+const fdeEnabled = "enabled"
+
+// defaultContainerRoles are the container roles recovery keys are added to when none is given, like snapd does.
+var defaultContainerRoles = []string{"system-data", "system-save"}
+
+// State is the desired FDE state of a machine. Everything which is not described is left as it is.
+type State struct {
+	// Auth is the authentication mode of the platform keys: none, pin or passphrase.
+	Auth string `yaml:"auth"`
+	// RecoveryKeys are the recovery keys which must exist.
+	RecoveryKeys []RecoveryKey `yaml:"recovery-keys"`
+}
+
+// RecoveryKey is a recovery key which must exist on the containers with the given roles.
+type RecoveryKey struct {
+	Name string `yaml:"name"`
+	// ContainerRoles defaults to system-data and system-save.
+	ContainerRoles []string `yaml:"container-roles"`
+}
+
+// Parse reads a YAML state. Unknown fields are rejected, so that typos don't go unnoticed.
+func Parse(r io.Reader) (State, error) {
+	var s State
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return s, fmt.Errorf("invalid state: %w", err)
+	}
+
+	if err := s.validate(); err != nil {
+		return s, fmt.Errorf("invalid state: %w", err)
+	}
+
+	return s, nil
+}
+
+// validate checks the state and normalizes its container roles.
+func (s *State) validate() error {
+	switch s.Auth {
+	case "", AuthNone, AuthPIN, AuthPassphrase:
+	default:
+		return fmt.Errorf("unsupported auth %q, must be one of %s, %s or %s", s.Auth, AuthNone, AuthPIN, AuthPassphrase)
+	}
+
+	seen := make(map[string]bool)
+	for i, k := range s.RecoveryKeys {
+		if k.Name == "" {
+			return fmt.Errorf("recovery key %d has no name", i+1)
+		}
+		if seen[k.Name] {
+			return fmt.Errorf("recovery key %q is described more than once", k.Name)
+		}
+		seen[k.Name] = true
+
+		roles := slices.Clone(k.ContainerRoles)
+		if len(roles) == 0 {
+			roles = slices.Clone(defaultContainerRoles)
+		}
+		slices.Sort(roles)
+		s.RecoveryKeys[i].ContainerRoles = slices.Compact(roles)
+	}
+
+	return nil
+}
+
+// ActionKind is the kind of change reconciling the system with the state.
+type ActionKind string
+
+// Supported kinds of actions.
+const (
+	// CreateRecoveryKey creates a missing recovery key.
+	CreateRecoveryKey ActionKind = "create-recovery-key"
+	// SetAuth changes the authentication mode of the platform keys.
+	SetAuth ActionKind = "set-auth"
+)
+
+// Action is a change reconciling the system with the state.
+type Action struct {
+	Kind ActionKind `json:"kind" yaml:"kind"`
+
+	// RecoveryKey and ContainerRoles are set when creating a recovery key.
+	RecoveryKey    string   `json:"recovery-key,omitempty" yaml:"recovery-key,omitempty"`
+	ContainerRoles []string `json:"container-roles,omitempty" yaml:"container-roles,omitempty"`
+
+	// Auth and CurrentAuth are set when changing the authentication mode.
+	Auth        string `json:"auth,omitempty" yaml:"auth,omitempty"`
+	CurrentAuth string `json:"current-auth,omitempty" yaml:"current-auth,omitempty"`
+}
+
+// String describes the action for humans.
+func (a Action) String() string {
+	switch a.Kind {
+	case CreateRecoveryKey:
+		return fmt.Sprintf("create recovery key %q on %s", a.RecoveryKey, strings.Join(a.ContainerRoles, ", "))
+	case SetAuth:
+		return fmt.Sprintf("change authentication from %s to %s", a.CurrentAuth, a.Auth)
+	}
+	return string(a.Kind)
+}
+
+// Plan is the list of changes reconciling the system with the state.
+type Plan struct {
+	// Actions are the changes to make, in the order they must be made.
+	Actions []Action `json:"actions" yaml:"actions"`
+	// Conflicts are the differences which can't be reconciled automatically.
+	Conflicts []string `json:"conflicts" yaml:"conflicts"`
+	// Unmanaged are the recovery keys of the system which are not described in the state. They are kept.
+	Unmanaged []string `json:"unmanaged" yaml:"unmanaged"`
+}
+
+// Diff plans the changes reconciling the system, described by its FDE status and volumes, with the state.
+// Recovery keys are created before the authentication mode is changed, so that the system can always
+// be recovered if the new authentication is lost.
+func Diff(s State, fdeStatus string, volumes snapd.SystemVolumesResult) (Plan, error) {
+	p := Plan{
+		Actions:   []Action{},
+		Conflicts: []string{},
+		Unmanaged: []string{},
+	}
+
+	if fdeStatus != fdeEnabled {
+		return p, fmt.Errorf("full disk encryption is not enabled: status is %q", fdeStatus)
+	}
+
+	existing := make(map[string]bool)
+	for role, structure := range volumes.ByContainerRole {
+		if structure.Encrypted {
+			existing[role] = true
+		}
+	}
+
+	recoveryKeys := recoveryKeyRoles(volumes)
+	for _, k := range s.RecoveryKeys {
+		for _, role := range k.ContainerRoles {
+			if !existing[role] {
+				return p, fmt.Errorf("recovery key %q: unknown container role %q", k.Name, role)
+			}
+		}
+
+		roles, found := recoveryKeys[k.Name]
+		switch {
+		case !found:
+			p.Actions = append(p.Actions, Action{
+				Kind:           CreateRecoveryKey,
+				RecoveryKey:    k.Name,
+				ContainerRoles: k.ContainerRoles,
+			})
+		case !slices.Equal(roles, k.ContainerRoles):
+			// Recovery keys can't be removed from a container, nor added to more containers under the same name.
+			p.Conflicts = append(p.Conflicts, fmt.Sprintf("recovery key %q is on %s instead of %s",
+				k.Name, strings.Join(roles, ", "), strings.Join(k.ContainerRoles, ", ")))
+		}
+	}
+
+	for name := range recoveryKeys {
+		if !slices.ContainsFunc(s.RecoveryKeys, func(k RecoveryKey) bool { return k.Name == name }) {
+			p.Unmanaged = append(p.Unmanaged, name)
+		}
+	}
+	slices.Sort(p.Unmanaged)
+
+	if s.Auth != "" {
+		if current := currentAuth(volumes); current != s.Auth {
+			p.Actions = append(p.Actions, Action{
+				Kind:        SetAuth,
+				Auth:        s.Auth,
+				CurrentAuth: current,
+			})
+		}
+	}
+
+	return p, nil
+}
+
+// HasChanges returns true if the system differs from the state.
+func (p Plan) HasChanges() bool {
+	return len(p.Actions) > 0 || len(p.Conflicts) > 0
+}
+
+// recoveryKeyRoles returns the sorted container roles of each recovery key of the system.
+func recoveryKeyRoles(volumes snapd.SystemVolumesResult) map[string][]string {
+	keys := make(map[string][]string)
+	for role, structure := range volumes.ByContainerRole {
+		for name, slot := range structure.Keyslots {
+			if snapd.IsRecoveryKey(slot) {
+				keys[name] = append(keys[name], role)
+			}
+		}
+	}
+
+	for name := range keys {
+		slices.Sort(keys[name])
+	}
+
+	return keys
+}
+
+// currentAuth returns the authentication mode of the platform keys of the system.
+// Platform keys with different authentication modes are reported as mixed.
+func currentAuth(volumes snapd.SystemVolumesResult) string {
+	var modes []string
+	for _, structure := range volumes.ByContainerRole {
+		for _, slot := range structure.Keyslots {
+			if snapd.IsPlatformKey(slot) {
+				modes = append(modes, string(slot.AuthMode))
+			}
+		}
+	}
+
+	slices.Sort(modes)
+	modes = slices.Compact(modes)
+
+	switch len(modes) {
+	case 0:
+		return "unknown"
+	case 1:
+		return modes[0]
+	default:
+		return "mixed (" + strings.Join(modes, ", ") + ")"
+	}
+}
@@ -0,0 +1,219 @@
+package fdestate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/fdestate"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content string
+
+		want    fdestate.State
+		wantErr bool
+	}{
+		"Parses_full_state": {
+			content: "auth: pin\nrecovery-keys:\n  - name: ops-2026\n  - name: escrow-a\n    container-roles: [system-save, system-data, system-save]\n",
+			want: fdestate.State{
+				Auth: fdestate.AuthPIN,
+				RecoveryKeys: []fdestate.RecoveryKey{
+					{Name: "ops-2026", ContainerRoles: []string{"system-data", "system-save"}},
+					{Name: "escrow-a", ContainerRoles: []string{"system-data", "system-save"}},
+				},
+			},
+		},
+		"Parses_recovery_key_on_a_single_container": {
+			content: "recovery-keys:\n  - name: save-key\n    container-roles: [system-save]\n",
+			want: fdestate.State{
+				RecoveryKeys: []fdestate.RecoveryKey{{Name: "save-key", ContainerRoles: []string{"system-save"}}},
+			},
+		},
+		"Parses_auth_only":   {content: "auth: none\n", want: fdestate.State{Auth: fdestate.AuthNone}},
+		"Parses_empty_state": {content: ""},
+
+		"Error_on_unknown_field":             {content: "auth: pin\npins: 1\n", wantErr: true},
+		"Error_on_unsupported_auth":          {content: "auth: fingerprint\n", wantErr: true},
+		"Error_on_recovery_key_without_name": {content: "recovery-keys:\n  - container-roles: [system-data]\n", wantErr: true},
+		"Error_on_duplicated_recovery_key":   {content: "recovery-keys:\n  - name: a\n  - name: a\n", wantErr: true},
+		"Error_on_invalid_yaml":              {content: "auth: [pin\n", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			got, err := fdestate.Parse(strings.NewReader(tc.content))
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.want) // Parse returns the expected state
+		})
+	}
+}
+
+// newVolumes returns encrypted system-data and system-save containers with the given recovery keys,
+// and platform keys protected with the given authentication modes.
+func newVolumes(recoveryKeys map[string][]string, dataAuth, saveAuth snapd.AuthMode) snapd.SystemVolumesResult {
+	volumes := snapd.SystemVolumesResult{
+		ByContainerRole: map[string]snapd.SystemVolumesStructureInfo{
+			"container1": {Name: "mbr", VolumeName: "pc"},
+		},
+	}
+
+	for role, auth := range map[string]snapd.AuthMode{"system-data": dataAuth, "system-save": saveAuth} {
+		keyslots := map[string]snapd.KeySlotInfo{
+			"default-fallback": {Type: "platform", PlatformName: "tpm2", AuthMode: auth},
+		}
+		for name, roles := range recoveryKeys {
+			for _, r := range roles {
+				if r == role {
+					keyslots[name] = snapd.KeySlotInfo{Type: "recovery"}
+				}
+			}
+		}
+		volumes.ByContainerRole[role] = snapd.SystemVolumesStructureInfo{Encrypted: true, Keyslots: keyslots}
+	}
+
+	return volumes
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	both := []string{"system-data", "system-save"}
+	defaultKeys := map[string][]string{"default-recovery": both}
+
+	tests := map[string]struct {
+		state        fdestate.State
+		fdeStatus    string
+		recoveryKeys map[string][]string
+		dataAuth     snapd.AuthMode
+		saveAuth     snapd.AuthMode
+
+		want    fdestate.Plan
+		wantErr bool
+	}{
+		"No_changes_when_system_matches": {
+			state: fdestate.State{Auth: fdestate.AuthPIN, RecoveryKeys: []fdestate.RecoveryKey{{Name: "default-recovery", ContainerRoles: both}}},
+			want:  fdestate.Plan{Actions: []fdestate.Action{}, Conflicts: []string{}, Unmanaged: []string{}},
+		},
+		"No_changes_for_empty_state": {
+			want: fdestate.Plan{Actions: []fdestate.Action{}, Conflicts: []string{}, Unmanaged: []string{"default-recovery"}},
+		},
+		"Creates_missing_recovery_keys_before_changing_auth": {
+			state: fdestate.State{
+				Auth: fdestate.AuthPassphrase,
+				RecoveryKeys: []fdestate.RecoveryKey{
+					{Name: "ops-2026", ContainerRoles: both},
+					{Name: "save-key", ContainerRoles: []string{"system-save"}},
+				},
+			},
+			want: fdestate.Plan{
+				Actions: []fdestate.Action{
+					{Kind: fdestate.CreateRecoveryKey, RecoveryKey: "ops-2026", ContainerRoles: both},
+					{Kind: fdestate.CreateRecoveryKey, RecoveryKey: "save-key", ContainerRoles: []string{"system-save"}},
+					{Kind: fdestate.SetAuth, Auth: fdestate.AuthPassphrase, CurrentAuth: fdestate.AuthPIN},
+				},
+				Conflicts: []string{},
+				Unmanaged: []string{"default-recovery"},
+			},
+		},
+		"Removes_auth": {
+			state: fdestate.State{Auth: fdestate.AuthNone},
+			want: fdestate.Plan{
+				Actions:   []fdestate.Action{{Kind: fdestate.SetAuth, Auth: fdestate.AuthNone, CurrentAuth: fdestate.AuthPIN}},
+				Conflicts: []string{},
+				Unmanaged: []string{"default-recovery"},
+			},
+		},
+		"Unifies_mixed_auth": {
+			state:    fdestate.State{Auth: fdestate.AuthPIN},
+			saveAuth: snapd.AuthModePassphrase,
+			want: fdestate.Plan{
+				Actions:   []fdestate.Action{{Kind: fdestate.SetAuth, Auth: fdestate.AuthPIN, CurrentAuth: "mixed (passphrase, pin)"}},
+				Conflicts: []string{},
+				Unmanaged: []string{"default-recovery"},
+			},
+		},
+		"Reports_recovery_key_on_other_containers_as_conflict": {
+			state:        fdestate.State{RecoveryKeys: []fdestate.RecoveryKey{{Name: "ops-2026", ContainerRoles: both}}},
+			recoveryKeys: map[string][]string{"ops-2026": {"system-data"}},
+			want: fdestate.Plan{
+				Actions:   []fdestate.Action{},
+				Conflicts: []string{`recovery key "ops-2026" is on system-data instead of system-data, system-save`},
+				Unmanaged: []string{},
+			},
+		},
+
+		"Error_when_FDE_is_disabled": {fdeStatus: "disabled", wantErr: true},
+		"Error_on_unknown_container_role": {
+			state:   fdestate.State{RecoveryKeys: []fdestate.RecoveryKey{{Name: "ops-2026", ContainerRoles: []string{"container1"}}}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			if tc.fdeStatus == "" {
+				tc.fdeStatus = "enabled"
+			}
+			if tc.recoveryKeys == nil {
+				tc.recoveryKeys = defaultKeys
+			}
+			if tc.dataAuth == "" {
+				tc.dataAuth = snapd.AuthModePIN
+			}
+			if tc.saveAuth == "" {
+				tc.saveAuth = snapd.AuthModePIN
+			}
+
+			got, err := fdestate.Diff(tc.state, tc.fdeStatus, newVolumes(tc.recoveryKeys, tc.dataAuth, tc.saveAuth))
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.want)                                                      // Diff returns the expected plan
+			is.Equal(got.HasChanges(), len(tc.want.Actions)+len(tc.want.Conflicts) > 0) // HasChanges reports differences
+		})
+	}
+}
+
+func TestActionString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		action fdestate.Action
+
+		want string
+	}{
+		"Creating_recovery_key": {
+			action: fdestate.Action{Kind: fdestate.CreateRecoveryKey, RecoveryKey: "ops-2026", ContainerRoles: []string{"system-data", "system-save"}},
+			want:   `create recovery key "ops-2026" on system-data, system-save`,
+		},
+		"Changing_auth": {
+			action: fdestate.Action{Kind: fdestate.SetAuth, Auth: fdestate.AuthPIN, CurrentAuth: fdestate.AuthPassphrase},
+			want:   "change authentication from passphrase to pin",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			is.Equal(tc.action.String(), tc.want) // String describes the action
+		})
+	}
+}
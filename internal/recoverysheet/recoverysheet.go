@@ -0,0 +1,50 @@
+// Package recoverysheet generates printable documents holding a recovery key, to be stored offline.
+package recoverysheet
+
+import (
+	_ "embed" // Required for go:embed directives
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"rsc.io/qr"
+)
+
+//go:embed sheet.html.tmpl
+var sheetTemplate string
+
+var tmpl = template.Must(template.New("sheet").Parse(sheetTemplate))
+
+// Sheet describes a recovery key and where it applies.
+type Sheet struct {
+	RecoveryKey    string
+	KeyID          string
+	Hostname       string
+	Date           time.Time
+	ContainerRoles []string
+}
+
+// Write renders the sheet as a self-contained HTML document, with the recovery key also encoded as a QR code.
+func Write(w io.Writer, s Sheet) error {
+	code, err := qr.Encode(s.RecoveryKey, qr.M)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	data := struct {
+		Sheet
+		QRCode template.URL
+	}{
+		Sheet: s,
+		//nolint:gosec // The URL only holds base64 encoded data we generated.
+		QRCode: template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(code.PNG())),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write recovery sheet: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package recoverysheet_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/snap-tpmctl/internal/recoverysheet"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		recoveryKey    string
+		keyID          string
+		containerRoles []string
+		writeError     bool
+
+		wantErr bool
+	}{
+		"Success_writing_sheet":                      {},
+		"Success_writing_sheet_with_one_role":        {containerRoles: []string{"system-save"}},
+		"Success_escaping_html_in_key_id":            {keyID: "<b>key</b>"},
+		"Error_when_recovery_key_is_too_long_for_qr": {recoveryKey: strings.Repeat("1", 8000), wantErr: true},
+		"Error_when_sheet_can_not_be_written":        {writeError: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			if tc.recoveryKey == "" {
+				tc.recoveryKey = "11272-47509-28031-54818-41671-38673-11053-06376"
+			}
+			if tc.keyID == "" {
+				tc.keyID = "my-key"
+			}
+			if tc.containerRoles == nil {
+				tc.containerRoles = []string{"system-data", "system-save"}
+			}
+
+			var out strings.Builder
+			var w io.Writer = &out
+			if tc.writeError {
+				w = failingWriter{}
+			}
+
+			err := recoverysheet.Write(w, recoverysheet.Sheet{
+				RecoveryKey:    tc.recoveryKey,
+				KeyID:          tc.keyID,
+				Hostname:       "my-host",
+				Date:           time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+				ContainerRoles: tc.containerRoles,
+			})
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			golden.CheckOrUpdate(t, out.String()) // TestWrite returns the expected document
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write error")
+}
@@ -0,0 +1,77 @@
+// Package secretgen generates strong passphrases and PINs offline, from a source of randomness like crypto/rand.
+package secretgen
+
+import (
+	"crypto/rand"
+	_ "embed" // Required to embed the wordlist.
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// wordlistData is the BIP-39 English wordlist: 2048 common words, each of them being identified by its first
+// 4 letters. Each random word of a passphrase adds 11 bits of entropy.
+//
+//go:embed wordlist.txt
+var wordlistData string
+
+var wordlist = strings.Fields(wordlistData)
+
+// separator joins the words of a passphrase.
+const separator = "-"
+
+const (
+	// MaxWords is the maximum number of words of a passphrase.
+	MaxWords = 12
+	// MaxPINLength is the maximum number of digits of a PIN.
+	MaxPINLength = 32
+)
+
+// Passphrase returns a diceware-style passphrase made of the given number of words, picked from the wordlist
+// with randomness read from r.
+func Passphrase(r io.Reader, words int) (string, error) {
+	if words < 1 || words > MaxWords {
+		return "", fmt.Errorf("number of words must be between 1 and %d, got %d", MaxWords, words)
+	}
+
+	picked := make([]string, words)
+	for i := range picked {
+		n, err := randomInt(r, len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		picked[i] = wordlist[n]
+	}
+
+	return strings.Join(picked, separator), nil
+}
+
+// PIN returns a PIN made of the given number of digits, with randomness read from r.
+func PIN(r io.Reader, length int) (string, error) {
+	if length < 1 || length > MaxPINLength {
+		return "", fmt.Errorf("PIN length must be between 1 and %d, got %d", MaxPINLength, length)
+	}
+
+	var b strings.Builder
+	for range length {
+		n, err := randomInt(r, 10)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strconv.Itoa(n))
+	}
+
+	return b.String(), nil
+}
+
+// randomInt returns a uniform random number in [0, maxN), with randomness read from r.
+func randomInt(r io.Reader, maxN int) (int, error) {
+	n, err := rand.Int(r, big.NewInt(int64(maxN)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+
+	return int(n.Int64()), nil
+}
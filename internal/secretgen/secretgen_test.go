@@ -0,0 +1,111 @@
+package secretgen_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	mathrand "math/rand/v2"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/secretgen"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestPassphrase(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		words int
+		rand  io.Reader
+
+		want    string
+		wantErr bool
+	}{
+		"Generates_one_word":                  {words: 1},
+		"Generates_default_number_of_words":   {words: 6},
+		"Generates_maximum_number_of_words":   {words: secretgen.MaxWords},
+		"Generates_same_passphrase_from_seed": {words: 4, rand: mathrand.NewChaCha8([32]byte{}), want: "couch-supply-plug-few"},
+
+		"Error_on_no_words":            {words: 0, wantErr: true},
+		"Error_on_too_many_words":      {words: secretgen.MaxWords + 1, wantErr: true},
+		"Error_when_randomness_failed": {words: 6, rand: failingReader{}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			if tc.rand == nil {
+				tc.rand = rand.Reader
+			}
+
+			got, err := secretgen.Passphrase(tc.rand, tc.words)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			words := strings.Split(got, "-")
+			is.Equal(len(words), tc.words) // Passphrase has the requested number of words
+			for _, w := range words {
+				is.True(regexp.MustCompile(`^[a-z]{3,8}$`).MatchString(w)) // Words come from the wordlist
+			}
+			if tc.want != "" {
+				is.Equal(got, tc.want) // Passphrase only depends on the randomness
+			}
+		})
+	}
+}
+
+func TestPIN(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		length int
+		rand   io.Reader
+
+		want    string
+		wantErr bool
+	}{
+		"Generates_one_digit":          {length: 1},
+		"Generates_default_length":     {length: 8},
+		"Generates_maximum_length":     {length: secretgen.MaxPINLength},
+		"Generates_same_PIN_from_seed": {length: 8, rand: mathrand.NewChaCha8([32]byte{}), want: "97616773"},
+
+		"Error_on_empty_PIN":           {length: 0, wantErr: true},
+		"Error_on_too_long_PIN":        {length: secretgen.MaxPINLength + 1, wantErr: true},
+		"Error_when_randomness_failed": {length: 8, rand: failingReader{}, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			if tc.rand == nil {
+				tc.rand = rand.Reader
+			}
+
+			got, err := secretgen.PIN(tc.rand, tc.length)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.True(regexp.MustCompile(`^[0-9]+$`).MatchString(got)) // PIN is only made of digits
+			is.Equal(len(got), tc.length)                            // PIN has the requested length
+			if tc.want != "" {
+				is.Equal(got, tc.want) // PIN only depends on the randomness
+			}
+		})
+	}
+}
+
+// failingReader is a source of randomness which always fails.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no randomness")
+}
@@ -0,0 +1,146 @@
+// Package shamir splits secrets into shares with Shamir's secret sharing over GF(2^8), so that any threshold
+// number of shares reconstruct the secret while fewer of them reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// MaxShares is the maximum number of shares a secret can be split into.
+const MaxShares = 255
+
+// Split divides secret into m shares, any n of which are needed to reconstruct it.
+// Each share is returned with its x coordinate, from 1 to m.
+func Split(secret []byte, n, m int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("cannot split an empty secret")
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2, got %d", n)
+	}
+	if m < n {
+		return nil, fmt.Errorf("number of shares (%d) must be greater than or equal to the threshold (%d)", m, n)
+	}
+	if m > MaxShares {
+		return nil, fmt.Errorf("number of shares must be at most %d, got %d", MaxShares, m)
+	}
+
+	shares := make([]Share, m)
+	for i := range shares {
+		shares[i] = Share{
+			Threshold: byte(n), //nolint:gosec // n is checked to be at most MaxShares.
+			Index:     byte(i + 1),
+			Value:     make([]byte, len(secret)),
+		}
+	}
+
+	// Each byte of the secret is the constant term of its own random polynomial of degree n-1.
+	coefficients := make([]byte, n)
+	for b, s := range secret {
+		coefficients[0] = s
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("failed to generate random coefficients: %w", err)
+		}
+
+		for i := range shares {
+			shares[i].Value[b] = evaluate(coefficients, shares[i].Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs the secret from at least the threshold number of distinct shares.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no share provided")
+	}
+
+	threshold := int(shares[0].Threshold)
+	size := len(shares[0].Value)
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if int(s.Threshold) != threshold || len(s.Value) != size {
+			return nil, errors.New("shares are not from the same secret")
+		}
+		if s.Index == 0 {
+			return nil, errors.New("invalid share index 0")
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("share %d is provided more than once", s.Index)
+		}
+		seen[s.Index] = true
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%d shares are needed to reconstruct the secret, got %d", threshold, len(shares))
+	}
+
+	// Lagrange interpolation at x = 0 of the first threshold shares.
+	shares = shares[:threshold]
+	secret := make([]byte, size)
+	for i, si := range shares {
+		// basis is the value at 0 of the Lagrange basis polynomial for share i.
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(sj.Index, add(sj.Index, si.Index)))
+		}
+
+		for b := range secret {
+			secret[b] = add(secret[b], mul(si.Value[b], basis))
+		}
+	}
+
+	return secret, nil
+}
+
+// evaluate returns the value of the polynomial with the given coefficients at x, using Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = add(mul(y, x), coefficients[i])
+	}
+	return y
+}
+
+// GF(2^8) arithmetic with the AES reducing polynomial x^8 + x^4 + x^3 + x + 1.
+var expTable, logTable = func() (exp [510]byte, log [256]byte) {
+	x := byte(1)
+	for i := range 255 {
+		exp[i] = x
+		exp[i+255] = x
+		log[x] = byte(i)
+
+		// Multiply by the generator 3 (x + 1).
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	return exp, log
+}()
+
+func add(a, b byte) byte {
+	return a ^ b
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+// div returns a/b. b must not be 0.
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
@@ -0,0 +1,152 @@
+package shamir_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/shamir"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+var secret = []byte{0x8f, 0x2c, 0x00, 0xff, 0x10, 0x42, 0x99, 0x01, 0xaa, 0x55, 0x7e, 0x3d, 0xc4, 0x00, 0x00, 0xee}
+
+func TestSplitAndCombine(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		n, m    int
+		combine []int
+		secret  []byte
+
+		wantErr      bool
+		wantSplitErr bool
+	}{
+		"Combines_2_of_2":                   {n: 2, m: 2, combine: []int{0, 1}},
+		"Combines_2_of_3_with_any_pair":     {n: 2, m: 3, combine: []int{2, 0}},
+		"Combines_3_of_5":                   {n: 3, m: 5, combine: []int{4, 1, 3}},
+		"Combines_with_more_than_threshold": {n: 3, m: 5, combine: []int{0, 1, 2, 3, 4}},
+		"Combines_255_shares":               {n: 255, m: 255, combine: seq(255)},
+		"Combines_one_byte_secret":          {n: 2, m: 3, combine: []int{0, 2}, secret: []byte{42}},
+
+		"Error_combining_fewer_than_threshold": {n: 3, m: 5, combine: []int{0, 1}, wantErr: true},
+		"Error_combining_same_share_twice":     {n: 2, m: 3, combine: []int{1, 1}, wantErr: true},
+		"Error_combining_no_share":             {n: 2, m: 3, combine: []int{}, wantErr: true},
+
+		"Error_splitting_with_threshold_of_1":          {n: 1, m: 3, wantSplitErr: true},
+		"Error_splitting_with_less_shares_than_needed": {n: 3, m: 2, wantSplitErr: true},
+		"Error_splitting_into_too_many_shares":         {n: 2, m: 256, wantSplitErr: true},
+		"Error_splitting_empty_secret":                 {n: 2, m: 3, secret: []byte{}, wantSplitErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			if tc.secret == nil {
+				tc.secret = secret
+			}
+
+			shares, err := shamir.Split(tc.secret, tc.n, tc.m)
+			if testutils.CheckError(is, err, tc.wantSplitErr) {
+				return
+			}
+			is.Equal(len(shares), tc.m) // Split returns the requested number of shares
+
+			var selected []shamir.Share
+			for _, i := range tc.combine {
+				selected = append(selected, shares[i])
+			}
+
+			got, err := shamir.Combine(selected)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.secret) // Combine reconstructs the secret
+		})
+	}
+}
+
+func TestSharesDoNotLeakSecret(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	shares, err := shamir.Split(secret, 2, 3)
+	is.NoErr(err)
+
+	for _, s := range shares {
+		is.True(!bytes.Equal(s.Value, secret)) // A single share is not the secret
+	}
+
+	// Splitting twice uses new random polynomials.
+	again, err := shamir.Split(secret, 2, 3)
+	is.NoErr(err)
+	is.True(!bytes.Equal(shares[0].Value, again[0].Value)) // Shares differ between splits
+}
+
+func TestCombineMismatchingShares(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	a, err := shamir.Split(secret, 2, 3)
+	is.NoErr(err)
+	b, err := shamir.Split(secret, 3, 3)
+	is.NoErr(err)
+
+	_, err = shamir.Combine([]shamir.Share{a[0], b[1], b[2]})
+	is.True(err != nil) // Shares with different thresholds can't be combined
+}
+
+func TestShareString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+
+		want    shamir.Share
+		wantErr bool
+	}{
+		"Parses_formatted_share":          {input: "{share}", want: shamir.Share{Threshold: 3, Index: 2, Value: secret}},
+		"Parses_share_without_separators": {input: "{compact}", want: shamir.Share{Threshold: 3, Index: 2, Value: secret}},
+		"Parses_share_in_upper_case":      {input: "{upper}", want: shamir.Share{Threshold: 3, Index: 2, Value: secret}},
+
+		"Error_on_typo":            {input: "{typo}", wantErr: true},
+		"Error_on_non_hexadecimal": {input: "0302-zzzz", wantErr: true},
+		"Error_on_too_short_share": {input: "0302", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			formatted := shamir.Share{Threshold: 3, Index: 2, Value: secret}.String()
+			is.Equal(formatted, "0302-8f2c-00ff-1042-9901-aa55-7e3d-c400-00ee-"+formatted[45:]) // Share is formatted in groups of 4 digits
+
+			input := strings.NewReplacer(
+				"{share}", formatted,
+				"{compact}", strings.ReplaceAll(formatted, "-", ""),
+				"{upper}", strings.ToUpper(formatted),
+				"{typo}", "0303"+formatted[4:],
+			).Replace(tc.input)
+
+			got, err := shamir.ParseShare(input)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.want) // ParseShare returns the formatted share
+		})
+	}
+}
+
+func seq(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// checksumSize is the number of bytes of the checksum detecting typos in a share entered by hand.
+const checksumSize = 2
+
+// shareGroupSize is the number of hexadecimal digits in each hyphen separated group of a formatted share.
+const shareGroupSize = 4
+
+// Share is a part of a split secret.
+type Share struct {
+	// Threshold is the number of shares needed to reconstruct the secret.
+	Threshold byte
+	// Index is the x coordinate of the share, from 1.
+	Index byte
+	// Value is the share of each byte of the secret.
+	Value []byte
+}
+
+// String formats the share as groups of hexadecimal digits, holding the threshold, the index, the value and a checksum.
+func (s Share) String() string {
+	data := s.bytes()
+	data = append(data, checksum(data)...)
+
+	encoded := hex.EncodeToString(data)
+	var groups []string
+	for len(encoded) > shareGroupSize {
+		groups = append(groups, encoded[:shareGroupSize])
+		encoded = encoded[shareGroupSize:]
+	}
+	groups = append(groups, encoded)
+
+	return strings.Join(groups, "-")
+}
+
+// ParseShare parses a share formatted by String. Separators and case are ignored.
+func ParseShare(s string) (Share, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return Share{}, errors.New("invalid share: only hexadecimal digits are expected")
+	}
+	if len(data) < 3+checksumSize {
+		return Share{}, errors.New("invalid share: too short")
+	}
+
+	payload, sum := data[:len(data)-checksumSize], data[len(data)-checksumSize:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return Share{}, errors.New("invalid share: checksum mismatch, check it for typos")
+	}
+
+	return Share{
+		Threshold: payload[0],
+		Index:     payload[1],
+		Value:     payload[2:],
+	}, nil
+}
+
+func (s Share) bytes() []byte {
+	return append([]byte{s.Threshold, s.Index}, s.Value...)
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:checksumSize]
+}
@@ -2,6 +2,8 @@ package snapd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	snapdClient "github.com/snapcore/snapd/client"
@@ -32,8 +34,31 @@ func (c *Client) ReplacePassphrase(ctx context.Context, oldPassphrase string, ne
 	return nil
 }
 
-// CheckPassphrase checks if the provided passphrase is valid.
-func (c *Client) CheckPassphrase(ctx context.Context, passphrase string) error {
+// Quality is the result of the quality checks of a PIN or passphrase.
+type Quality struct {
+	Entropy        uint32   `json:"entropy-bits"`
+	MinEntropy     uint32   `json:"min-entropy-bits"`
+	OptimalEntropy uint32   `json:"optimal-entropy-bits"`
+	Reasons        []string `json:"reasons,omitempty"`
+}
+
+// Quality returns the quality of the PIN or passphrase refused by snapd, if the error is about one.
+func (e *Error) Quality() (Quality, bool) {
+	var quality Quality
+	if e.Kind != snapdClient.ErrorKindInvalidPIN && e.Kind != snapdClient.ErrorKindInvalidPassphrase {
+		return quality, false
+	}
+
+	if err := json.Unmarshal(e.Value, &quality); err != nil {
+		return quality, false
+	}
+
+	return quality, true
+}
+
+// CheckPassphrase checks if the provided passphrase is valid, and returns its quality.
+// When the passphrase is refused, the quality is available from the returned Error.
+func (c *Client) CheckPassphrase(ctx context.Context, passphrase string) (Quality, error) {
 	body := struct {
 		Action     string `json:"action"`
 		Passphrase string `json:"passphrase"`
@@ -42,15 +67,12 @@ func (c *Client) CheckPassphrase(ctx context.Context, passphrase string) error {
 		Passphrase: passphrase,
 	}
 
-	if _, err := c.doSyncRequest(ctx, http.MethodPost, "/v2/system-volumes", nil, nil, body); err != nil {
-		return err
-	}
-
-	return nil
+	return c.checkQuality(ctx, body)
 }
 
-// CheckPIN checks if the provided PIN is valid.
-func (c *Client) CheckPIN(ctx context.Context, pin string) error {
+// CheckPIN checks if the provided PIN is valid, and returns its quality.
+// When the PIN is refused, the quality is available from the returned Error.
+func (c *Client) CheckPIN(ctx context.Context, pin string) (Quality, error) {
 	body := struct {
 		Action string `json:"action"`
 		PIN    string `json:"pin"`
@@ -59,11 +81,26 @@ func (c *Client) CheckPIN(ctx context.Context, pin string) error {
 		PIN:    pin,
 	}
 
-	if _, err := c.doSyncRequest(ctx, http.MethodPost, "/v2/system-volumes", nil, nil, body); err != nil {
-		return err
+	return c.checkQuality(ctx, body)
+}
+
+// checkQuality sends the quality check request of a PIN or passphrase and decodes its result.
+func (c *Client) checkQuality(ctx context.Context, body any) (quality Quality, err error) {
+	resp, err := c.doSyncRequest(ctx, http.MethodPost, "/v2/system-volumes", nil, nil, body)
+	if err != nil {
+		return quality, err
 	}
 
-	return nil
+	// Older versions of snapd don't report the quality.
+	if len(resp.Result) == 0 {
+		return quality, nil
+	}
+
+	if err := json.Unmarshal(resp.Result, &quality); err != nil {
+		return quality, fmt.Errorf("failed to decode the quality: %w", err)
+	}
+
+	return quality, nil
 }
 
 // ReplacePIN replaces a PIN to the specified keyslots.
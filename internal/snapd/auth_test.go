@@ -1,6 +1,7 @@
 package snapd_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/snapd"
@@ -40,17 +41,28 @@ func TestReplacePassphrase(t *testing.T) {
 	}
 }
 
-func TestCheckPassphrase(t *testing.T) {
+func TestCheckSecret(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		passphrase string
+		pin    bool
+		secret string
 
+		want    snapd.Quality
 		wantErr bool
 	}{
-		"Passphrase_is_valid": {passphrase: "test12345"},
-
-		"Error_on_low_quality_passphrase": {wantErr: true},
+		"Passphrase_is_valid": {secret: "test12345", want: snapd.Quality{Entropy: 42, MinEntropy: 42, OptimalEntropy: 100}},
+		"PIN_is_valid":        {pin: true, secret: "12345", want: snapd.Quality{Entropy: 42, MinEntropy: 42, OptimalEntropy: 100}},
+
+		"Error_on_low_quality_passphrase": {
+			want:    snapd.Quality{Entropy: 7, MinEntropy: 42, OptimalEntropy: 100, Reasons: []string{"low-entropy"}},
+			wantErr: true,
+		},
+		"Error_on_low_quality_pin": {
+			pin:     true,
+			want:    snapd.Quality{Entropy: 6, MinEntropy: 13, OptimalEntropy: 64, Reasons: []string{"low-entropy"}},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range tests {
@@ -61,39 +73,20 @@ func TestCheckPassphrase(t *testing.T) {
 
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 
-			err := c.CheckPassphrase(ctx, tc.passphrase)
-			if testutils.CheckError(is, err, tc.wantErr) {
-				return
+			check := c.CheckPassphrase
+			if tc.pin {
+				check = c.CheckPIN
 			}
-		})
-	}
-}
-
-func TestCheckPIN(t *testing.T) {
-	t.Parallel()
-
-	tests := map[string]struct {
-		pin string
-
-		wantErr bool
-	}{
-		"PIN_is_valid": {pin: "12345"},
 
-		"Error_on_low_quality_pin": {wantErr: true},
-	}
-
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			t.Parallel()
-			is := is.New(t)
-			ctx := testutils.ContextLoggerWithDebug(t)
-
-			c := snapdtestutils.NewMockSnapdServer(t, ctx)
-
-			err := c.CheckPIN(ctx, tc.pin)
+			got, err := check(ctx, tc.secret)
 			if testutils.CheckError(is, err, tc.wantErr) {
-				return
+				snapdErr, ok := errors.AsType[*snapd.Error](err)
+				is.True(ok) // Error is a snapd error
+				got, ok = snapdErr.Quality()
+				is.True(ok) // Error has a quality
 			}
+
+			is.Equal(got, tc.want) // Quality does not match
 		})
 	}
 }
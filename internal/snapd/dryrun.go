@@ -0,0 +1,57 @@
+package snapd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+)
+
+// Request is a request changing the system which was not sent to snapd. Secrets of its body are redacted.
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// DryRunFunc is called with each request changing the system instead of sending it to snapd.
+type DryRunFunc func(Request)
+
+type dryRunKeyType string
+
+const dryRunKey dryRunKeyType = "dry-run"
+
+// WithDryRun returns a context on which asynchronous operations, which are the ones changing the system,
+// are reported to fn instead of being sent to snapd, as is the generation of recovery keys.
+// Other synchronous requests, reading or validating, are still sent.
+func WithDryRun(ctx context.Context, fn DryRunFunc) context.Context {
+	return context.WithValue(ctx, dryRunKey, fn)
+}
+
+// dryRun reports the request to the dry-run function embedded into the context, if any,
+// and returns true if the request must not be sent.
+func dryRun(ctx context.Context, method, path string, body []byte) bool {
+	fn, ok := ctx.Value(dryRunKey).(DryRunFunc)
+	if !ok || fn == nil {
+		return false
+	}
+
+	fn(Request{
+		Method: method,
+		Path:   path,
+		Body:   redact(body),
+	})
+
+	return true
+}
+
+// dryRunRequest reports a synchronous request changing the system like dryRun, encoding its body first.
+func dryRunRequest(ctx context.Context, method, path string, body any) bool {
+	b, err := json.Marshal(body)
+	if err != nil || !dryRun(ctx, method, path, b) {
+		return false
+	}
+
+	log.Debug(ctx, "Not sending %v %v to snapd in dry-run mode", method, path)
+	return true
+}
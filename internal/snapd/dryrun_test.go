@@ -0,0 +1,56 @@
+package snapd_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		call func(context.Context, *snapd.Client) error
+	}{
+		"Reports_replacing_platform_key_with_pin_redacted": {call: func(ctx context.Context, c *snapd.Client) error {
+			return c.ReplacePlatformKey(ctx, snapd.AuthModePIN, "12345")
+		}},
+		"Reports_replacing_passphrase_with_both_redacted": {call: func(ctx context.Context, c *snapd.Client) error {
+			return c.ReplacePassphrase(ctx, "old passphrase", "new passphrase", []snapd.Keyslot{{Name: "default"}})
+		}},
+		"Reports_adding_recovery_key": {call: func(ctx context.Context, c *snapd.Client) error {
+			return c.AddRecoveryKey(ctx, "7", []snapd.Keyslot{{ContainerRole: "system-data", Name: "ops"}})
+		}},
+		"Reports_generating_recovery_key": {call: func(ctx context.Context, c *snapd.Client) error {
+			_, err := c.GenerateRecoveryKey(ctx)
+			return err
+		}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+
+			var got []snapd.Request
+			ctx = snapd.WithDryRun(ctx, func(r snapd.Request) {
+				got = append(got, r)
+			})
+
+			err := tc.call(ctx, c.Client)
+			is.NoErr(err)
+
+			is.Equal(len(c.Requests), 0) // Nothing is sent to snapd
+
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
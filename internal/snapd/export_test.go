@@ -0,0 +1,5 @@
+package snapd
+
+var Redact = redact
+
+var WithBaseURL = withBaseURL
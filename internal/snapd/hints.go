@@ -0,0 +1,126 @@
+package snapd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	snapdClient "github.com/snapcore/snapd/client"
+)
+
+// kindHints is the catalog of explanations of the snapd error kinds, with the next steps to take.
+var kindHints = map[snapdClient.ErrorKind]func(e *Error) string{
+	snapdClient.ErrorKindInvalidPIN: func(e *Error) string {
+		return qualityHint("PIN", "use a longer PIN, avoiding repeated or sequential digits", e)
+	},
+	snapdClient.ErrorKindInvalidPassphrase: func(e *Error) string {
+		return qualityHint("passphrase", "use a longer passphrase, like several random words", e)
+	},
+	snapdClient.ErrorKindInvalidRecoveryKey: recoveryKeyHint,
+	snapdClient.ErrorKindKeyslotsAlreadyExists: staticHint(
+		"a key with this name already exists: choose another name, or regenerate it with regenerate-recovery-key"),
+	snapdClient.ErrorKindKeyslotsNotFound: staticHint(
+		"the key was not found: list the existing ones with list-all"),
+	snapdClient.ErrorKindInsufficientContainerCapacity: staticHint(
+		"the encrypted container has no free keyslot left: remove a key which is not used anymore first"),
+	snapdClient.ErrorKindSnapChangeConflict: staticHint(
+		"another FDE change is in progress: wait for it to complete, and follow it with 'snap changes'"),
+	snapdClient.ErrorKindUnsupportedByTargetSystem: staticHint(
+		"this action is not supported on this system: check that FDE is enabled with 'snap-tpmctl status'"),
+	snapdClient.ErrorKindLoginRequired: staticHint(
+		"permission denied: run the command with sudo"),
+}
+
+// changeHints is the catalog of explanations of the known errors of failed changes,
+// matched on the kind of the change and on a pattern of its error.
+var changeHints = []struct {
+	changeKind string
+	pattern    string
+	hint       string
+}{
+	{"fde-change-pin", "No key available with this passphrase", "the current PIN is wrong: check it and try again"},
+	{"fde-change-passphrase", "No key available with this passphrase", "the current passphrase is wrong: check it and try again"},
+}
+
+// unreachableHint explains ErrUnreachable.
+const unreachableHint = "snapd is not running: check its status with 'systemctl status snapd.socket'"
+
+// Explain returns the message of err, with the snapd error it wraps replaced by a short explanation
+// and the next steps to take. It returns false if there is no known explanation for err.
+func Explain(err error) (string, bool) {
+	var cause, hint string
+
+	if snapdErr, ok := errors.AsType[*Error](err); ok {
+		cause, hint = snapdErr.Error(), snapdErr.hint()
+	} else if errors.Is(err, ErrUnreachable) {
+		cause, hint = ErrUnreachable.Error(), unreachableHint
+	}
+	if hint == "" {
+		return "", false
+	}
+
+	// Keep what the error says about the failed operation, which wraps the cause.
+	msg := err.Error()
+	if i := strings.LastIndex(msg, cause); i >= 0 {
+		return msg[:i] + hint, true
+	}
+
+	return hint, true
+}
+
+// hint returns the explanation of the error from the catalogs, if any.
+func (e *Error) hint() string {
+	if e.ChangeID != "" {
+		for _, h := range changeHints {
+			if h.changeKind == e.ChangeKind && strings.Contains(e.Message, h.pattern) {
+				return h.hint
+			}
+		}
+		return ""
+	}
+
+	if fn, ok := kindHints[e.Kind]; ok {
+		return fn(e)
+	}
+
+	return ""
+}
+
+// staticHint returns a hint function always explaining the error with hint.
+func staticHint(hint string) func(*Error) string {
+	return func(*Error) string { return hint }
+}
+
+// qualityHint explains a PIN or passphrase refused by the quality checks, using the entropy reported by snapd.
+func qualityHint(secret, advice string, e *Error) string {
+	quality, ok := e.Quality()
+	if !ok || quality.MinEntropy <= quality.Entropy {
+		return fmt.Sprintf("%s too weak: %s", secret, advice)
+	}
+
+	missing := quality.MinEntropy - quality.Entropy
+	bits := "bits"
+	if missing == 1 {
+		bits = "bit"
+	}
+
+	return fmt.Sprintf("%s too weak: needs %d more %s of entropy, %s", secret, missing, bits, advice)
+}
+
+// recoveryKeyHint explains why a recovery key was refused, from the reason reported by snapd.
+func recoveryKeyHint(e *Error) string {
+	var v struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(e.Value, &v)
+
+	switch v.Reason {
+	case "invalid-format":
+		return "the recovery key is malformed: it is made of 8 groups of 5 digits, like 12345-12345-12345-12345-12345-12345-12345-12345"
+	case "expired", "not-found":
+		return "the new recovery key expired before it was used: run the command again"
+	}
+
+	return "the recovery key does not work: check that it is the one saved when it was created"
+}
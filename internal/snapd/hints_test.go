@@ -0,0 +1,75 @@
+package snapd_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+	snapdClient "github.com/snapcore/snapd/client"
+)
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err error
+
+		wantNoHint bool
+	}{
+		"Explains_weak_PIN_with_missing_entropy": {err: &snapd.Error{
+			Kind:  snapdClient.ErrorKindInvalidPIN,
+			Value: []byte(`{"entropy-bits":6,"min-entropy-bits":13,"optimal-entropy-bits":64,"reasons":["low-entropy"]}`),
+		}},
+		"Explains_weak_passphrase_missing_one_bit": {err: &snapd.Error{
+			Kind:  snapdClient.ErrorKindInvalidPassphrase,
+			Value: []byte(`{"entropy-bits":41,"min-entropy-bits":42}`),
+		}},
+		"Explains_weak_passphrase_without_entropy": {err: &snapd.Error{Kind: snapdClient.ErrorKindInvalidPassphrase}},
+		"Explains_malformed_recovery_key": {err: &snapd.Error{
+			Kind:  snapdClient.ErrorKindInvalidRecoveryKey,
+			Value: []byte(`{"reason":"invalid-format"}`),
+		}},
+		"Explains_expired_recovery_key": {err: &snapd.Error{
+			Kind:  snapdClient.ErrorKindInvalidRecoveryKey,
+			Value: []byte(`{"reason":"expired"}`),
+		}},
+		"Explains_wrong_recovery_key":    {err: &snapd.Error{Kind: snapdClient.ErrorKindInvalidRecoveryKey}},
+		"Explains_existing_keyslots":     {err: &snapd.Error{Kind: snapdClient.ErrorKindKeyslotsAlreadyExists}},
+		"Explains_conflicting_change":    {err: &snapd.Error{Kind: snapdClient.ErrorKindSnapChangeConflict}},
+		"Explains_unreachable_snapd":     {err: fmt.Errorf("failed to add PIN: %w: %w", snapd.ErrUnreachable, errors.New("connection refused"))},
+		"Keeps_operation_of_wrapped_err": {err: fmt.Errorf("failed to add PIN: %w", &snapd.Error{Message: "PIN did not pass quality checks", Kind: snapdClient.ErrorKindInvalidPIN})},
+		"Explains_wrong_current_PIN": {err: &snapd.Error{
+			Message:    "cannot perform the following tasks:\n- Change PIN (cryptsetup failed with: No key available with this passphrase.)",
+			ChangeID:   "42",
+			ChangeKind: "fde-change-pin",
+		}},
+		"Explains_wrong_current_passphrase": {err: &snapd.Error{
+			Message:    "cryptsetup failed with: No key available with this passphrase.",
+			ChangeID:   "42",
+			ChangeKind: "fde-change-passphrase",
+		}},
+
+		"No_hint_for_unknown_kind":           {err: &snapd.Error{Kind: snapdClient.ErrorKindBadQuery}, wantNoHint: true},
+		"No_hint_for_unknown_change_error":   {err: &snapd.Error{Message: "cannot perform", ChangeID: "42", ChangeKind: "fde-change-pin"}, wantNoHint: true},
+		"No_hint_for_errors_not_from_snapd":  {err: errors.New("some error"), wantNoHint: true},
+		"No_hint_for_same_pattern_elsewhere": {err: &snapd.Error{Message: "No key available with this passphrase", ChangeID: "42", ChangeKind: "fde-replace-platform-key"}, wantNoHint: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			got, ok := snapd.Explain(tc.err)
+			is.Equal(ok, !tc.wantNoHint) // Explain returns whether the error is known
+			if tc.wantNoHint {
+				return
+			}
+
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
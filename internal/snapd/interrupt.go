@@ -0,0 +1,90 @@
+package snapd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+)
+
+// abortTimeout is how long we wait for snapd to undo an aborted change.
+const abortTimeout = 30 * time.Second
+
+var (
+	// ErrChangeAborted is returned when the operation was interrupted and its change aborted in snapd.
+	ErrChangeAborted = errors.New("operation interrupted, the change was aborted")
+	// ErrChangeDetached is returned when the operation was interrupted and its change left running in snapd.
+	ErrChangeDetached = errors.New("operation interrupted, the change keeps running in the background")
+)
+
+// InterruptAction is what to do with a running change when the operation waiting for it is interrupted.
+type InterruptAction int
+
+const (
+	// InterruptAbort asks snapd to abort the change and to undo its completed tasks.
+	InterruptAbort InterruptAction = iota
+	// InterruptDetach leaves the change running in snapd.
+	InterruptDetach
+)
+
+// InterruptFunc decides what to do with a running change when the context waiting for it is cancelled.
+type InterruptFunc func(changeID string) InterruptAction
+
+type interruptKeyType string
+
+const interruptKey interruptKeyType = "interrupt"
+
+// WithInterrupt returns a context on which asynchronous operations ask fn what to do with their change on cancellation.
+// Without it, the change is aborted.
+func WithInterrupt(ctx context.Context, fn InterruptFunc) context.Context {
+	return context.WithValue(ctx, interruptKey, fn)
+}
+
+// AbortChange asks snapd to abort the given change.
+func (c *Client) AbortChange(ctx context.Context, changeID string) error {
+	body := struct {
+		Action string `json:"action"`
+	}{
+		Action: "abort",
+	}
+
+	if _, err := c.doSyncRequest(ctx, http.MethodPost, "/v2/changes/"+changeID, nil, nil, body); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// interruptChange aborts or detaches from a change whose waiting context was cancelled.
+func (c *Client) interruptChange(ctx context.Context, changeID string) error {
+	action := InterruptAbort
+	if fn, ok := ctx.Value(interruptKey).(InterruptFunc); ok && fn != nil {
+		action = fn(changeID)
+	}
+
+	if action == InterruptDetach {
+		log.Debug(ctx, "Detaching from change %s", changeID)
+		return fmt.Errorf("%w (run 'snap tasks %s' to follow it)", ErrChangeDetached, changeID)
+	}
+
+	// The original context is done: keep its values but give snapd some time to undo the change.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), abortTimeout)
+	defer cancel()
+
+	log.Debug(ctx, "Aborting change %s", changeID)
+	if err := c.AbortChange(ctx, changeID); err != nil {
+		return fmt.Errorf("could not abort change %s: %w", changeID, err)
+	}
+
+	err := c.waitChange(ctx, changeID)
+	if ctx.Err() != nil {
+		return fmt.Errorf("change %s still not undone after %s: %w", changeID, abortTimeout, err)
+	}
+	// An aborted change is expected to end in error, which is not the one we want to report.
+	log.Debug(ctx, "Aborted change %s ended with: %v", changeID, err)
+
+	return ErrChangeAborted
+}
@@ -0,0 +1,91 @@
+package snapd_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestInterrupt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		noInterruptFunc bool
+		action          snapd.InterruptAction
+		cancelledBefore bool
+
+		wantAbort bool
+		wantErr   error
+	}{
+		"Aborts_change_by_default":        {noInterruptFunc: true, wantAbort: true, wantErr: snapd.ErrChangeAborted},
+		"Aborts_change_when_asked":        {action: snapd.InterruptAbort, wantAbort: true, wantErr: snapd.ErrChangeAborted},
+		"Detaches_from_change_when_asked": {action: snapd.InterruptDetach, wantErr: snapd.ErrChangeDetached},
+
+		"Error_when_abort_is_refused":                  {wantAbort: true},
+		"Error_without_starting_change_when_cancelled": {cancelledBefore: true, wantErr: context.Canceled},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+
+			// Interrupt the operation as soon as the change is reported as running.
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			ctx = snapd.WithProgress(ctx, func(p snapd.ChangeProgress) {
+				if !p.Ready {
+					cancel()
+				}
+			})
+
+			var gotChangeID string
+			if !tc.noInterruptFunc {
+				ctx = snapd.WithInterrupt(ctx, func(changeID string) snapd.InterruptAction {
+					gotChangeID = changeID
+					return tc.action
+				})
+			}
+
+			if tc.cancelledBefore {
+				cancel()
+			}
+
+			err := c.ReplacePlatformKey(ctx, snapd.AuthModePIN, "12345")
+			is.True(err != nil) // ReplacePlatformKey returns an error when interrupted
+			if tc.wantErr != nil {
+				is.True(errors.Is(err, tc.wantErr)) // ReplacePlatformKey returns the expected error
+			} else {
+				is.True(!errors.Is(err, snapd.ErrChangeAborted)) // Failing abort is not reported as done
+			}
+
+			if tc.cancelledBefore {
+				is.Equal(len(c.Requests), 0) // No change is started once cancelled
+				is.Equal(gotChangeID, "")    // Interrupt function is not called without a running change
+				return
+			}
+
+			if !tc.noInterruptFunc {
+				is.Equal(gotChangeID, "11") // Interrupt function is called with the running change
+			}
+
+			var gotAbort bool
+			for _, r := range c.Requests {
+				if r.Method == http.MethodPost && r.Path == "/v2/changes/11" {
+					is.Equal(r.Body, `{"action":"abort"}`+"\n") // Abort request body
+					gotAbort = true
+				}
+			}
+			is.Equal(gotAbort, tc.wantAbort) // Abort request sent to snapd
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package snapd
+
+import (
+	"context"
+
+	snapdClient "github.com/snapcore/snapd/client"
+)
+
+// ChangeProgress describes the current state of an asynchronous snapd change.
+type ChangeProgress struct {
+	Summary string
+	Status  string
+	Ready   bool
+	Tasks   []TaskProgress
+}
+
+// TaskProgress describes the current state of a single task of a snapd change.
+type TaskProgress struct {
+	Summary string
+	Status  string
+	Done    int
+	Total   int
+}
+
+// ProgressFunc is called each time the state of a running change is retrieved from snapd.
+type ProgressFunc func(ChangeProgress)
+
+type progressKeyType string
+
+const progressKey progressKeyType = "progress"
+
+// WithProgress returns a context on which asynchronous operations report the progress of their change to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey, fn)
+}
+
+// reportProgress forwards the state of the change to the progress function embedded into the context, if any.
+func reportProgress(ctx context.Context, change *snapdClient.Change) {
+	fn, ok := ctx.Value(progressKey).(ProgressFunc)
+	if !ok || fn == nil {
+		return
+	}
+
+	p := ChangeProgress{
+		Summary: change.Summary,
+		Status:  change.Status,
+		Ready:   change.Ready,
+	}
+	for _, t := range change.Tasks {
+		p.Tasks = append(p.Tasks, TaskProgress{
+			Summary: t.Summary,
+			Status:  t.Status,
+			Done:    t.Progress.Done,
+			Total:   t.Progress.Total,
+		})
+	}
+
+	fn(p)
+}
@@ -0,0 +1,44 @@
+package snapd_test
+
+import (
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/matryer/is"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		wantErr bool
+	}{
+		"Reports_each_change_state_until_ready": {},
+		"Reports_change_already_done":           {},
+
+		"Reports_failed_change": {wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+
+			var got []snapd.ChangeProgress
+			ctx = snapd.WithProgress(ctx, func(p snapd.ChangeProgress) {
+				got = append(got, p)
+			})
+
+			err := c.ReplacePlatformKey(ctx, snapd.AuthModePIN, "12345")
+			testutils.CheckError(is, err, tc.wantErr)
+
+			golden.CheckOrUpdate(t, got)
+		})
+	}
+}
@@ -23,7 +23,12 @@ type GenerateRecoveryKeyResult struct {
 	KeyID       string `json:"key-id"`
 }
 
+// dryRunKeyID stands for the ID of the recovery key which would be generated in dry-run mode.
+const dryRunKeyID = "<generated-key-id>"
+
 // GenerateRecoveryKey creates a new recovery key and returns the key and its ID.
+// Though synchronous, the request registers the key in snapd: in dry-run mode, it is reported instead of being sent,
+// and no recovery key is returned.
 func (c *Client) GenerateRecoveryKey(ctx context.Context) (result GenerateRecoveryKeyResult, err error) {
 	body := struct {
 		Action string `json:"action"`
@@ -31,6 +36,10 @@ func (c *Client) GenerateRecoveryKey(ctx context.Context) (result GenerateRecove
 		Action: "generate-recovery-key",
 	}
 
+	if dryRunRequest(ctx, http.MethodPost, "/v2/system-volumes", body) {
+		return GenerateRecoveryKeyResult{KeyID: dryRunKeyID}, nil
+	}
+
 	resp, err := c.doSyncRequest(ctx, http.MethodPost, "/v2/system-volumes", nil, nil, &body)
 	if err != nil {
 		return result, err
@@ -88,7 +97,7 @@ func (c *Client) CheckRecoveryKey(ctx context.Context, recoveryKey string, conta
 	body := struct {
 		Action         string   `json:"action"`
 		RecoveryKey    string   `json:"recovery-key"`
-		ContainerRoles []string `json:"container-role"`
+		ContainerRoles []string `json:"container-roles,omitempty"`
 	}{
 		Action:         "check-recovery-key",
 		RecoveryKey:    recoveryKey,
@@ -0,0 +1,65 @@
+package snapd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// redactedValue replaces the value of any sensitive field in logged payloads.
+const redactedValue = "<redacted>"
+
+// sensitiveFields is the registry of JSON field names carrying secrets in snapd requests and responses.
+// Redaction is keyed on the field name only, so any new action reusing one of those fields is covered.
+var sensitiveFields = map[string]bool{
+	"passphrase":     true,
+	"old-passphrase": true,
+	"new-passphrase": true,
+	"pin":            true,
+	"old-pin":        true,
+	"new-pin":        true,
+	"recovery-key":   true,
+}
+
+// redact returns a printable copy of the JSON payload with all sensitive fields masked at any depth.
+// Payloads which are not valid JSON are never returned as is, as we can't tell what they contain.
+func redact(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return fmt.Sprintf("<%d bytes of non JSON payload>", len(payload))
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(v)); err != nil {
+		return fmt.Sprintf("<%d bytes of unprintable payload>", len(payload))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// redactValue walks a decoded JSON value and masks the sensitive fields of every object.
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if sensitiveFields[k] {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = redactValue(child)
+		}
+	case []any:
+		for i, child := range val {
+			val[i] = redactValue(child)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,121 @@
+package snapd_test
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/log"
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/matryer/is"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		payload string
+
+		want string
+	}{
+		"Payload_without_secrets_is_kept": {payload: `{"action":"generate-recovery-key"}`, want: `{"action":"generate-recovery-key"}`},
+		"Passphrases_are_redacted": {
+			payload: `{"action":"change-passphrase","old-passphrase":"old","new-passphrase":"new"}`,
+			want:    `{"action":"change-passphrase","new-passphrase":"<redacted>","old-passphrase":"<redacted>"}`,
+		},
+		"PINs_are_redacted": {
+			payload: `{"action":"change-pin","old-pin":"1234","new-pin":"4321"}`,
+			want:    `{"action":"change-pin","new-pin":"<redacted>","old-pin":"<redacted>"}`,
+		},
+		"Nested_secrets_are_redacted": {
+			payload: `{"result":[{"recovery-key":"12345","key-id":"id"}],"pin":"1234"}`,
+			want:    `{"pin":"<redacted>","result":[{"key-id":"id","recovery-key":"<redacted>"}]}`,
+		},
+		"Non_object_payload_is_kept": {payload: `"enabled"`, want: `"enabled"`},
+		"Empty_payload":              {payload: "", want: ""},
+
+		"Non_JSON_payload_is_hidden": {payload: `passphrase=secret`, want: "<17 bytes of non JSON payload>"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			got := snapd.Redact([]byte(tc.payload))
+			is.Equal(got, tc.want) // Redact returns the expected payload
+		})
+	}
+}
+
+func TestRedactedLogs(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		call    func(ctx context.Context, c *snapd.Client) error
+		secrets []string
+
+		wantErr bool
+	}{
+		"ReplacePassphrase": {
+			call: func(ctx context.Context, c *snapd.Client) error {
+				return c.ReplacePassphrase(ctx, "old-secret", "new-secret", nil)
+			},
+			secrets: []string{"old-secret", "new-secret"},
+		},
+		"CheckPIN": {
+			call: func(ctx context.Context, c *snapd.Client) error {
+				_, err := c.CheckPIN(ctx, "987654")
+				return err
+			},
+			secrets: []string{"987654"},
+		},
+		"CheckRecoveryKey": {
+			call: func(ctx context.Context, c *snapd.Client) error {
+				_, err := c.CheckRecoveryKey(ctx, "11272-47509-28031-54818-41671-38673-11053-06376", nil)
+				return err
+			},
+			secrets: []string{"11272-47509-28031-54818-41671-38673-11053-06376"},
+		},
+		"GenerateRecoveryKey": {
+			call: func(ctx context.Context, c *snapd.Client) error {
+				_, err := c.GenerateRecoveryKey(ctx)
+				return err
+			},
+			secrets: []string{"11272-47509-28031-54818-41671-38673-11053-06376"},
+		},
+
+		"Error_on_low_quality_passphrase": {
+			call: func(ctx context.Context, c *snapd.Client) error {
+				_, err := c.CheckPassphrase(ctx, "weak-secret")
+				return err
+			},
+			secrets: []string{"weak-secret"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			// The mock server logs raw payloads: keep them out of the checked logs.
+			c := snapdtestutils.NewMockSnapdServer(t, t.Context())
+
+			ctx, logs := testutils.TestLoggerWithBuffer(t)
+			log.SetLoggerLevelInContext(ctx, slog.LevelDebug)
+
+			err := tc.call(ctx, c.Client)
+			testutils.CheckError(is, err, tc.wantErr)
+
+			is.True(strings.Contains(logs.String(), "<redacted>")) // Secrets are replaced in logs
+			for _, secret := range tc.secrets {
+				is.True(!strings.Contains(logs.String(), secret)) // Secret leaked in logs
+			}
+		})
+	}
+}
@@ -20,29 +20,44 @@ import (
 const (
 	defaultSocketPath = "/var/run/snapd.socket"
 	defaultUserAgent  = "snapd.go"
+
+	// noticeTimeout is how long we wait for a change update before refreshing the change state anyway.
+	noticeTimeout = "10s"
 )
 
+// ErrUnreachable is returned when snapd can't be reached.
+var ErrUnreachable = errors.New("snapd is unreachable")
+
 // Error represents an error from snapd.
 type Error struct {
 	Message string
 	Kind    snapdClient.ErrorKind
 	Value   json.RawMessage
+
+	// ChangeID and ChangeKind are set when the request was accepted, but its change failed.
+	ChangeID   string
+	ChangeKind string
 }
 
 // newErrorFromSnapdError attempts to converts a snapdClient.Error to an internal snapd.Error with JSON-marshaled Value.
 // If the provided error is not a snapdClient.Error or if marshaling the Value fails, it returns the original error.
+// Connection errors are reported as ErrUnreachable.
 func newErrorFromSnapdError(ctx context.Context, err error) error {
+	if _, ok := errors.AsType[snapdClient.ConnectionError](err); ok {
+		return fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+
 	snapdErr, ok := errors.AsType[*snapdClient.Error](err)
 	if !ok {
 		return err
 	}
 
-	log.Debug(ctx, "Received an error from snapd: %q", snapdErr.Value)
-
 	value, e := json.Marshal(snapdErr.Value)
 	if e != nil {
 		return err
 	}
+
+	log.Debug(ctx, "Received an error from snapd: %s", redact(value))
 	return &Error{
 		Kind:    snapdErr.Kind,
 		Message: snapdErr.Message,
@@ -97,19 +112,31 @@ func addGenericHeaders(headers map[string]string) map[string]string {
 	return headers
 }
 
-// notice polls the snapd notices endpoint for change updates until completion or timeout.
-func (c *Client) notice(ctx context.Context, changeID string) error {
+// notice waits on the snapd notices endpoint for an update of the change posted after the given time, or timeout.
+func (c *Client) notice(ctx context.Context, changeID string, after time.Time) error {
 	query := url.Values{}
-	query.Add("after", time.Now().UTC().Format(time.RFC3339Nano))
+	query.Add("after", after.UTC().Format(time.RFC3339Nano))
 	query.Add("keys", changeID)
-	query.Add("timeout", "1h")
+	query.Add("timeout", noticeTimeout)
 	query.Add("types", "change-update")
 
-	if _, err := c.doSyncRequest(ctx, http.MethodGet, "/v2/notices", query, nil, nil); err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	return nil
+	// The snapd client can't be cancelled: stop waiting for its answer as soon as the context is done.
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.doSyncRequest(ctx, http.MethodGet, "/v2/notices", query, nil, nil)
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // response is the base response structure from snapd.
@@ -119,14 +146,14 @@ type response struct {
 
 // doSyncRequest performs a synchronous request to snapd and returns the response.
 //
-//nolint:unparam // path parameter kept for future extensibility
+//nolint:unparam // headers parameter kept for future extensibility
 func (c *Client) doSyncRequest(ctx context.Context, method, path string, query url.Values, headers map[string]string, body any) (*response, error) {
 	var b bytes.Buffer
 	if err := json.NewEncoder(&b).Encode(&body); err != nil {
 		return nil, err
 	}
 
-	log.Debug(ctx, "Sending %v %v to snapd %q", method, path, b.String())
+	log.Debug(ctx, "Sending %v %v to snapd %s", method, path, redact(b.Bytes()))
 
 	var result json.RawMessage
 	_, err := doSync(c.snapd, method, path, query, addGenericHeaders(headers), &b, &result)
@@ -134,7 +161,7 @@ func (c *Client) doSyncRequest(ctx context.Context, method, path string, query u
 		return nil, err
 	}
 
-	log.Debug(ctx, "Received result from snapd: %q", result)
+	log.Debug(ctx, "Received result from snapd: %s", redact(result))
 
 	return &response{Result: result}, nil
 }
@@ -146,31 +173,57 @@ func (c *Client) doAsyncRequest(ctx context.Context, method, path string, query
 		return err
 	}
 
-	log.Debug(ctx, "Sending asynchronously %v %v to snapd %q", method, path, b.String())
+	if dryRun(ctx, method, path, b.Bytes()) {
+		log.Debug(ctx, "Not sending %v %v to snapd in dry-run mode", method, path)
+		return nil
+	}
 
-	changeID, err := doAsync(c.snapd, method, path, query, addGenericHeaders(headers), &b)
-	if err := newErrorFromSnapdError(ctx, err); err != nil {
+	// Don't start a change which would be interrupted right away.
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	// wait for the task to be completed
-	if err := c.notice(ctx, changeID); err != nil {
+	log.Debug(ctx, "Sending asynchronously %v %v to snapd %s", method, path, redact(b.Bytes()))
+
+	changeID, err := doAsync(c.snapd, method, path, query, addGenericHeaders(headers), &b)
+	if err := newErrorFromSnapdError(ctx, err); err != nil {
 		return err
 	}
 
-	// retrieve informations about the change
-	change, err := c.snapd.Change(changeID)
-	if err != nil {
-		return err
+	err = c.waitChange(ctx, changeID)
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return c.interruptChange(ctx, changeID)
 	}
 
-	if change.Err != "" {
-		return &Error{
-			Message: change.Err,
+	return err
+}
+
+// waitChange refreshes the change state on each update until it is done, or the context is cancelled.
+func (c *Client) waitChange(ctx context.Context, changeID string) error {
+	for {
+		after := time.Now()
+
+		change, err := c.snapd.Change(changeID)
+		if err != nil {
+			return newErrorFromSnapdError(ctx, err)
+		}
+		reportProgress(ctx, change)
+
+		if change.Ready {
+			if change.Err != "" {
+				return &Error{
+					Message:    change.Err,
+					ChangeID:   changeID,
+					ChangeKind: change.Kind,
+				}
+			}
+			return nil
 		}
-	}
 
-	return nil
+		if err := c.notice(ctx, changeID, after); err != nil {
+			return err
+		}
+	}
 }
 
 //go:linkname doSync github.com/snapcore/snapd/client.(*Client).doSync
@@ -1,10 +1,16 @@
 package snapd_test
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/snapd"
+	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils"
 	"github.com/matryer/is"
+	snapdClient "github.com/snapcore/snapd/client"
 )
 
 // Mock for snapd client backend (http)
@@ -16,3 +22,47 @@ func TestNew(t *testing.T) {
 	s := snapd.New()
 	is.True(s != nil) // New returned an object
 }
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		unreachable bool
+
+		wantUnreachable bool
+		wantKind        snapdClient.ErrorKind
+		wantChangeID    string
+	}{
+		"Returns_kind_of_refused_request":  {wantKind: snapdClient.ErrorKindInvalidPIN},
+		"Returns_id_of_failed_change":      {wantChangeID: "670"},
+		"Returns_unreachable_when_offline": {unreachable: true, wantUnreachable: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx).Client
+			if tc.unreachable {
+				ts := httptest.NewServer(http.NotFoundHandler())
+				ts.Close()
+				c = snapd.New(snapd.WithBaseURL(ts.URL))
+			}
+
+			err := c.ReplacePlatformKey(ctx, snapd.AuthModePIN, "12345")
+			is.True(err != nil) // ReplacePlatformKey returns an error
+
+			is.Equal(errors.Is(err, snapd.ErrUnreachable), tc.wantUnreachable) // Error tells whether snapd is unreachable
+			if tc.wantUnreachable {
+				return
+			}
+
+			snapdErr, ok := errors.AsType[*snapd.Error](err)
+			is.True(ok)                                  // Error is a snapd error
+			is.Equal(snapdErr.Kind, tc.wantKind)         // Error has the expected kind
+			is.Equal(snapdErr.ChangeID, tc.wantChangeID) // Error has the expected change ID
+		})
+	}
+}
@@ -25,6 +25,11 @@ func IsRecoveryKey(slot KeySlotInfo) bool {
 	return slot.Type == snapdClient.KeyslotTypeRecovery
 }
 
+// IsPlatformKey returns true if the keyslot is a platform key, unlocking the container through the TPM.
+func IsPlatformKey(slot KeySlotInfo) bool {
+	return slot.Type == snapdClient.KeyslotTypePlatform
+}
+
 // IsPassphrase returns true if the keyslot uses passphrase authentication.
 func IsPassphrase(slot KeySlotInfo) bool {
 	return slot.AuthMode == AuthModePassphrase
@@ -37,6 +37,20 @@ type RecordedRequest struct {
 	Body   string
 }
 
+type options struct {
+	onRequest func(method, path string)
+}
+
+// Option is a function that configures a MockSnapdServer.
+type Option func(*options)
+
+// WithOnRequest is an option that calls f with each request received by the server, before answering it.
+func WithOnRequest(f func(method, path string)) Option {
+	return func(o *options) {
+		o.onRequest = f
+	}
+}
+
 type MockSnapdServer struct {
 	*snapd.Client
 
@@ -52,10 +66,15 @@ type MockSnapdServer struct {
 // <url-path> is the URL path of the request and <currentRequest> is the number of times that a request with
 // the same method and URL path has been received by the server.
 // If no match is found, a 404 response is returned.
-func NewMockSnapdServer(t *testing.T, ctx context.Context) *MockSnapdServer {
+func NewMockSnapdServer(t *testing.T, ctx context.Context, args ...Option) *MockSnapdServer {
 	t.Helper()
 	is := is.New(t)
 
+	o := options{}
+	for _, f := range args {
+		f(&o)
+	}
+
 	root := testutils.TestPath(t)
 
 	m := MockSnapdServer{
@@ -74,6 +93,10 @@ func NewMockSnapdServer(t *testing.T, ctx context.Context) *MockSnapdServer {
 			Body:   string(b),
 		})
 
+		if o.onRequest != nil {
+			o.onRequest(r.Method, r.URL.Path)
+		}
+
 		// Search for response in <root>/<method>/<url-path>:<currentRequest> and fallback to <root>/<method>/<url-path>.
 		var resp []byte
 		uri := filepath.Join(root, r.Method, r.URL.Path)
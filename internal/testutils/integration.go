@@ -87,7 +87,7 @@ func BuildSnapTpmCtl() (string, func(), error) {
 
 	if err := cmd.Run(); err != nil {
 		cleanup()
-		return "", nil, fmt.Errorf("failed to build snap-tpmctl: %v", err)
+		return "", nil, fmt.Errorf("failed to build snap-tpmctl: %w", err)
 	}
 
 	return execPath, cleanup, err
@@ -0,0 +1,109 @@
+package tpm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/snapcore/secboot"
+	"github.com/snapcore/secboot/luks2"
+	_ "github.com/snapcore/secboot/tpm2" // Required to unlock volumes with their TPM platform keys.
+)
+
+// UnlockAuth is the credential a volume is unlocked with.
+type UnlockAuth string
+
+const (
+	// UnlockAuthRecovery unlocks the volume with a recovery key.
+	UnlockAuthRecovery UnlockAuth = "recovery"
+	// UnlockAuthPassphrase unlocks the volume with its platform key, or else with a passphrase.
+	UnlockAuthPassphrase UnlockAuth = "passphrase"
+	// UnlockAuthPIN unlocks the volume with its platform key, or else with a PIN.
+	UnlockAuthPIN UnlockAuth = "pin"
+	// UnlockAuthAuto unlocks the volume with its platform key, or else with any credential it has a keyslot for.
+	UnlockAuthAuto UnlockAuth = "auto"
+)
+
+// UnlockAuths are the credentials volumes can be unlocked with.
+var UnlockAuths = []UnlockAuth{UnlockAuthRecovery, UnlockAuthPassphrase, UnlockAuthPIN, UnlockAuthAuto}
+
+// MaxUnlockTries is how many times each type of credential is asked before unlocking a volume fails.
+const MaxUnlockTries = 3
+
+// UnlockTries are how many times each type of credential can be tried to unlock a volume.
+// Types of credential with no tries are not asked.
+type UnlockTries struct {
+	Passphrase  uint
+	PIN         uint
+	RecoveryKey uint
+}
+
+// activator unlocks volumes with the keyslots of their LUKS2 header.
+type activator interface {
+	Activate(ctx context.Context, volumeName, device string, authRequestor secboot.AuthRequestor, tries UnlockTries) error
+}
+
+// validateUnlockAuth checks that volumes can be unlocked with auth.
+func validateUnlockAuth(auth UnlockAuth) error {
+	if auth == "" || slices.Contains(UnlockAuths, auth) {
+		return nil
+	}
+
+	names := make([]string, 0, len(UnlockAuths))
+	for _, a := range UnlockAuths {
+		names = append(names, string(a))
+	}
+
+	return fmt.Errorf("unsupported unlock authentication %q, must be one of %s", auth, strings.Join(names, ", "))
+}
+
+// activate unlocks the volume on device as volumeName with the given credential, asked with authRequestor.
+// The recovery key is used by default.
+func (s SnapTPM) activate(ctx context.Context, volumeName, device string, auth UnlockAuth, authRequestor secboot.AuthRequestor) error {
+	var tries UnlockTries
+	switch auth {
+	case "", UnlockAuthRecovery:
+		return secboot.ActivateVolumeWithRecoveryKey(
+			volumeName,
+			device,
+			authRequestor,
+			&secboot.ActivateVolumeOptions{
+				RecoveryKeyTries: MaxUnlockTries,
+			})
+	case UnlockAuthPassphrase:
+		tries = UnlockTries{Passphrase: MaxUnlockTries}
+	case UnlockAuthPIN:
+		tries = UnlockTries{PIN: MaxUnlockTries}
+	case UnlockAuthAuto:
+		tries = UnlockTries{Passphrase: MaxUnlockTries, PIN: MaxUnlockTries, RecoveryKey: MaxUnlockTries}
+	}
+
+	return s.activator.Activate(ctx, volumeName, device, authRequestor, tries)
+}
+
+type defaultActivator struct{}
+
+// Activate tries the platform keys of the volume first, which don't need any credential,
+// then asks for the credentials with tries left.
+func (defaultActivator) Activate(ctx context.Context, volumeName, device string, authRequestor secboot.AuthRequestor, tries UnlockTries) error {
+	container, err := secboot.FindStorageContainer(ctx, device)
+	if err != nil {
+		return fmt.Errorf("unable to find LUKS2 container: %w", err)
+	}
+
+	actx, err := secboot.NewActivateContext(ctx, nil,
+		secboot.WithAuthRequestor(authRequestor),
+		secboot.WithPassphraseTries(tries.Passphrase),
+		secboot.WithPINTries(tries.PIN),
+		secboot.WithRecoveryKeyTries(tries.RecoveryKey),
+	)
+	if err != nil {
+		return err
+	}
+
+	return actx.ActivateContainer(ctx, container,
+		luks2.WithVolumeName(volumeName),
+		secboot.WithAuthRequestorUserVisibleName(volumeName),
+	)
+}
@@ -7,27 +7,44 @@ import (
 	"github.com/canonical/snap-tpmctl/internal/snapd"
 )
 
+// CheckPassphrase checks the quality of a new passphrase.
+// When it is refused, the returned error wraps a snapd.Error providing its quality.
+func (s SnapTPM) CheckPassphrase(ctx context.Context, passphrase string) (snapd.Quality, error) {
+	quality, err := s.snapdClient.CheckPassphrase(ctx, passphrase)
+	if err != nil {
+		return quality, fmt.Errorf("failed to check passphrase: %w", err)
+	}
+
+	return quality, nil
+}
+
 // AddPassphrase adds passphrase authentication to the platform key.
 func (s SnapTPM) AddPassphrase(ctx context.Context, passphrase string) error {
-	if err := s.snapdClient.CheckPassphrase(ctx, passphrase); err != nil {
-		return fmt.Errorf("failed to check passphrase: %v", err)
+	if _, err := s.CheckPassphrase(ctx, passphrase); err != nil {
+		return err
 	}
 
 	if err := s.snapdClient.ReplacePlatformKey(ctx, snapd.AuthModePassphrase, passphrase); err != nil {
-		return fmt.Errorf("failed to add passphrase: %v", err)
+		return fmt.Errorf("failed to add passphrase: %w", err)
 	}
 
 	return nil
 }
 
 // ReplacePassphrase replaces the passphrase.
-func (s SnapTPM) ReplacePassphrase(ctx context.Context, oldPassphrase, newPassphrase string) error {
-	if err := s.snapdClient.CheckPassphrase(ctx, newPassphrase); err != nil {
-		return fmt.Errorf("failed to check passphrase: %v", err)
+// Only the containers with the given roles are updated, or the default keyslots of snapd if none is given.
+func (s SnapTPM) ReplacePassphrase(ctx context.Context, oldPassphrase, newPassphrase string, containerRoles []string) error {
+	if _, err := s.CheckPassphrase(ctx, newPassphrase); err != nil {
+		return err
+	}
+
+	keySlots, err := s.platformKeyslots(ctx, snapd.AuthModePassphrase, containerRoles)
+	if err != nil {
+		return err
 	}
 
-	if err := s.snapdClient.ReplacePassphrase(ctx, oldPassphrase, newPassphrase, nil); err != nil {
-		return fmt.Errorf("failed to change passphrase: %v", err)
+	if err := s.snapdClient.ReplacePassphrase(ctx, oldPassphrase, newPassphrase, keySlots); err != nil {
+		return fmt.Errorf("failed to change passphrase: %w", err)
 	}
 
 	return nil
@@ -36,33 +53,50 @@ func (s SnapTPM) ReplacePassphrase(ctx context.Context, oldPassphrase, newPassph
 // RemovePassphrase removes passphrase authentication from the platform key.
 func (s SnapTPM) RemovePassphrase(ctx context.Context) error {
 	if err := s.snapdClient.ReplacePlatformKey(ctx, snapd.AuthModeNone, ""); err != nil {
-		return fmt.Errorf("failed to remove passphrase: %v", err)
+		return fmt.Errorf("failed to remove passphrase: %w", err)
 	}
 
 	return nil
 }
 
+// CheckPIN checks the quality of a new PIN.
+// When it is refused, the returned error wraps a snapd.Error providing its quality.
+func (s SnapTPM) CheckPIN(ctx context.Context, pin string) (snapd.Quality, error) {
+	quality, err := s.snapdClient.CheckPIN(ctx, pin)
+	if err != nil {
+		return quality, fmt.Errorf("failed to validate PIN: %w", err)
+	}
+
+	return quality, nil
+}
+
 // AddPIN adds PIN authentication to the platform key.
 func (s SnapTPM) AddPIN(ctx context.Context, pin string) error {
-	if err := s.snapdClient.CheckPIN(ctx, pin); err != nil {
-		return fmt.Errorf("failed to validate PIN: %v", err)
+	if _, err := s.CheckPIN(ctx, pin); err != nil {
+		return err
 	}
 
 	if err := s.snapdClient.ReplacePlatformKey(ctx, snapd.AuthModePIN, pin); err != nil {
-		return fmt.Errorf("failed to add PIN: %v", err)
+		return fmt.Errorf("failed to add PIN: %w", err)
 	}
 
 	return nil
 }
 
 // ReplacePIN replaces the PIN using the provided client.
-func (s SnapTPM) ReplacePIN(ctx context.Context, oldPIN, newPIN string) error {
-	if err := s.snapdClient.CheckPIN(ctx, newPIN); err != nil {
-		return fmt.Errorf("failed to validate PIN: %v", err)
+// Only the containers with the given roles are updated, or the default keyslots of snapd if none is given.
+func (s SnapTPM) ReplacePIN(ctx context.Context, oldPIN, newPIN string, containerRoles []string) error {
+	if _, err := s.CheckPIN(ctx, newPIN); err != nil {
+		return err
 	}
 
-	if err := s.snapdClient.ReplacePIN(ctx, oldPIN, newPIN, nil); err != nil {
-		return fmt.Errorf("failed to change PIN: %v", err)
+	keySlots, err := s.platformKeyslots(ctx, snapd.AuthModePIN, containerRoles)
+	if err != nil {
+		return err
+	}
+
+	if err := s.snapdClient.ReplacePIN(ctx, oldPIN, newPIN, keySlots); err != nil {
+		return fmt.Errorf("failed to change PIN: %w", err)
 	}
 
 	return nil
@@ -71,7 +105,33 @@ func (s SnapTPM) ReplacePIN(ctx context.Context, oldPIN, newPIN string) error {
 // RemovePIN removes PIN authentication from the platform key.
 func (s SnapTPM) RemovePIN(ctx context.Context) error {
 	if err := s.snapdClient.ReplacePlatformKey(ctx, snapd.AuthModeNone, ""); err != nil {
-		return fmt.Errorf("failed to remove PIN: %v", err)
+		return fmt.Errorf("failed to remove PIN: %w", err)
+	}
+
+	return nil
+}
+
+// ResetAuth resets the authentication of the platform key to the given mode, without the current PIN or
+// passphrase, like when it was forgotten. A new PIN or passphrase is checked first, and secret is ignored
+// when authentication is removed.
+func (s SnapTPM) ResetAuth(ctx context.Context, authMode snapd.AuthMode, secret string) error {
+	var err error
+	switch authMode {
+	case snapd.AuthModePIN:
+		_, err = s.CheckPIN(ctx, secret)
+	case snapd.AuthModePassphrase:
+		_, err = s.CheckPassphrase(ctx, secret)
+	case snapd.AuthModeNone:
+		secret = ""
+	default:
+		return fmt.Errorf("unsupported authentication mode %q", authMode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.snapdClient.ReplacePlatformKey(ctx, authMode, secret); err != nil {
+		return fmt.Errorf("failed to reset authentication: %w", err)
 	}
 
 	return nil
@@ -1,6 +1,8 @@
 package tpm_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/snapd"
@@ -11,6 +13,56 @@ import (
 	"github.com/matryer/is"
 )
 
+func TestCheckSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		pin bool
+
+		want    snapd.Quality
+		wantErr bool
+	}{
+		"Success_checking_passphrase": {want: snapd.Quality{Entropy: 42, MinEntropy: 42, OptimalEntropy: 100}},
+		"Success_checking_PIN":        {pin: true, want: snapd.Quality{Entropy: 42, MinEntropy: 42, OptimalEntropy: 100}},
+
+		"Error_on_low_quality_passphrase": {
+			want:    snapd.Quality{Entropy: 7, MinEntropy: 42, OptimalEntropy: 100, Reasons: []string{"low-entropy"}},
+			wantErr: true,
+		},
+		"Error_on_low_quality_PIN": {
+			pin:     true,
+			want:    snapd.Quality{Entropy: 6, MinEntropy: 13, OptimalEntropy: 64, Reasons: []string{"low-entropy"}},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+
+			check := s.CheckPassphrase
+			if tc.pin {
+				check = s.CheckPIN
+			}
+
+			got, err := check(ctx, "secret")
+			if testutils.CheckError(is, err, tc.wantErr) {
+				snapdErr, ok := errors.AsType[*snapd.Error](err)
+				is.True(ok) // Error wraps the snapd error
+				got, ok = snapdErr.Quality()
+				is.True(ok) // Refused secret has a quality
+			}
+
+			is.Equal(got, tc.want) // Quality does not match
+		})
+	}
+}
+
 func TestAddPassphrase(t *testing.T) {
 	t.Parallel()
 
@@ -47,14 +99,23 @@ func TestReplacePassphrase(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		old string
-		new string
+		old            string
+		new            string
+		containerRoles []string
 
-		wantErr bool
+		wantInBody string
+		wantErr    bool
 	}{
 		"Success_replacing_passphrase": {old: "old", new: "new"},
-
-		"Error_replacing_passphrase": {wantErr: true},
+		"Success_replacing_passphrase_on_container_role": {
+			old:            "old",
+			new:            "new",
+			containerRoles: []string{"system-save"},
+			wantInBody:     `"keyslots":[{"container-role":"system-save","name":"default-fallback"}]`,
+		},
+
+		"Error_replacing_passphrase":      {wantErr: true},
+		"Error_on_unknown_container_role": {containerRoles: []string{"system-boot"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -66,12 +127,15 @@ func TestReplacePassphrase(t *testing.T) {
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 
-			err := s.ReplacePassphrase(ctx, tc.old, tc.new)
+			err := s.ReplacePassphrase(ctx, tc.old, tc.new, tc.containerRoles)
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
 
 			tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.old, tc.new)
+			if tc.wantInBody != "" {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.wantInBody)
+			}
 		})
 	}
 }
@@ -142,14 +206,17 @@ func TestReplacePIN(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		old string
-		new string
+		old            string
+		new            string
+		containerRoles []string
 
 		wantErr bool
 	}{
 		"Success_replacing_pin": {old: "123456", new: "654321"},
 
-		"Error_replacing_pin": {wantErr: true},
+		"Error_replacing_pin":                       {wantErr: true},
+		"Error_on_unknown_container_role":           {old: "123456", new: "654321", containerRoles: []string{"system-boot"}, wantErr: true},
+		"Error_on_no_pin_keyslot_in_container_role": {old: "123456", new: "654321", containerRoles: []string{"system-save"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -161,7 +228,7 @@ func TestReplacePIN(t *testing.T) {
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 
-			err := s.ReplacePIN(ctx, tc.old, tc.new)
+			err := s.ReplacePIN(ctx, tc.old, tc.new, tc.containerRoles)
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
@@ -200,3 +267,47 @@ func TestRemovePIN(t *testing.T) {
 		})
 	}
 }
+
+func TestResetAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		authMode snapd.AuthMode
+		secret   string
+
+		wantNotInRequests string
+		wantErr           bool
+	}{
+		"Success_resetting_to_PIN":        {authMode: snapd.AuthModePIN, secret: "1234"},
+		"Success_resetting_to_passphrase": {authMode: snapd.AuthModePassphrase, secret: "test"},
+		"Success_removing_authentication": {authMode: snapd.AuthModeNone, secret: "ignored", wantNotInRequests: "ignored"},
+
+		"Error_on_weak_PIN":              {authMode: snapd.AuthModePIN, secret: "1", wantErr: true},
+		"Error_when_reset_fails":         {authMode: snapd.AuthModeNone, wantErr: true},
+		"Error_on_unsupported_auth_mode": {authMode: "fingerprint", secret: "test", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			c := snapdtestutils.NewMockSnapdServer(t, ctx)
+			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
+
+			err := s.ResetAuth(ctx, tc.authMode, tc.secret)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			if tc.wantNotInRequests != "" {
+				for _, r := range c.Requests {
+					is.True(!strings.Contains(r.Body, tc.wantNotInRequests)) // Secret is not sent when authentication is removed
+				}
+				tc.secret = ""
+			}
+			tpmtestutils.OneRequestBodyContains(is, c.Requests, `"action":"replace-platform-key"`, string(tc.authMode), tc.secret)
+		})
+	}
+}
@@ -1,5 +1,19 @@
 package tpm
 
-type MountsFiledType = mountsFieldType
+type MountInfo = mountInfo
 
-var SearchInProcMounts = SnapTPM.searchInProcMounts
+var ReadMountInfo = SnapTPM.readMountInfo
+
+var ProbeFilesystem = probeFilesystem
+
+var IsCreatedMountPoint = SnapTPM.isCreatedMountPoint
+
+var RecordCreatedMountPoint = SnapTPM.recordCreatedMountPoint
+
+var ForgetCreatedMountPoint = SnapTPM.forgetCreatedMountPoint
+
+var IsActivatedVolume = SnapTPM.isActivatedVolume
+
+var RecordActivatedVolume = SnapTPM.recordActivatedVolume
+
+var ValidateMountOptions = MountOptions.validate
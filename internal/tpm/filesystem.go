@@ -0,0 +1,145 @@
+package tpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Filesystems are the filesystem types volumes can be mounted with.
+// They must be kept in sync with the mount-control plug of the snap, which confines the mounts to them.
+var Filesystems = []string{"ext2", "ext3", "ext4", "xfs", "vfat", "ntfs"}
+
+// mountFlags are the mount options volumes can be mounted with, and their flags.
+// They must be kept in sync with the mount-control plug of the snap, whose rules only allow mounts with exactly
+// these options.
+var mountFlags = map[string]uintptr{
+	"rw":       0,
+	"relatime": syscall.MS_RELATIME,
+}
+
+// MountOptions are the options a volume is mounted with.
+type MountOptions struct {
+	// FSType is the filesystem type of the volume. It is detected from its superblock when empty.
+	FSType string
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool
+	// Options are additional mount options, like relatime.
+	Options []string
+	// Auth is the credential the volume is unlocked with. A recovery key is used when empty.
+	Auth UnlockAuth
+}
+
+// MountOptionNames returns the mount options volumes can be mounted with.
+func MountOptionNames() []string {
+	names := make([]string, 0, len(mountFlags))
+	for name := range mountFlags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// validate checks that the volume can be unlocked with the options, and that the mount options are allowed
+// by the confinement of the snap.
+func (o MountOptions) validate() error {
+	if err := validateUnlockAuth(o.Auth); err != nil {
+		return err
+	}
+
+	if o.FSType != "" && !slices.Contains(Filesystems, o.FSType) {
+		return fmt.Errorf("unsupported filesystem type %q, must be one of %s", o.FSType, strings.Join(Filesystems, ", "))
+	}
+
+	// The confinement of the snap does not allow read-only mounts.
+	if o.ReadOnly {
+		return fmt.Errorf("unsupported read-only mount, the snap can only mount with %s", strings.Join(MountOptionNames(), ", "))
+	}
+	for _, opt := range o.Options {
+		if _, ok := mountFlags[opt]; !ok {
+			return fmt.Errorf("unsupported mount option %q, the snap can only mount with %s", opt, strings.Join(MountOptionNames(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// flags returns the mount flags of the options, whose names must be valid.
+// Access times are always updated relatively to modification times, like the snap is confined to.
+func (o MountOptions) flags() uintptr {
+	flags := uintptr(syscall.MS_RELATIME)
+	for _, opt := range o.Options {
+		flags |= mountFlags[opt]
+	}
+
+	return flags
+}
+
+// Superblock features of ext filesystems, telling ext2, ext3 and ext4 apart.
+const (
+	extFeatureCompatHasJournal = 0x4
+
+	// extFeatureIncompatExt3 are the incompatible features supported by ext3.
+	extFeatureIncompatExt3 = 0x2 | 0x4 | 0x10 // filetype, recover, meta_bg
+	// extFeatureROCompatExt3 are the read-only compatible features supported by ext3.
+	extFeatureROCompatExt3 = 0x1 | 0x2 | 0x4 // sparse_super, large_file, btree_dir
+)
+
+// probeFilesystem returns the type of the filesystem on the device at path, detected from its superblock.
+func probeFilesystem(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to open device: %w", err)
+	}
+	defer f.Close()
+
+	// The ext superblock is the furthest one, at 1024 bytes.
+	buf := make([]byte, 2048)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("unable to read superblock: %w", err)
+	}
+	buf = buf[:n]
+
+	switch {
+	// NTFS boot sectors are also FAT-like ones: check them first.
+	case hasBytesAt(buf, 3, "NTFS    "):
+		return "ntfs", nil
+	case hasBytesAt(buf, 510, "\x55\xaa") && (hasBytesAt(buf, 0x36, "FAT") || hasBytesAt(buf, 0x52, "FAT32")):
+		return "vfat", nil
+	case hasBytesAt(buf, 0, "XFSB"):
+		return "xfs", nil
+	case len(buf) >= 1024+0x68 && binary.LittleEndian.Uint16(buf[1024+0x38:]) == 0xef53:
+		return extVersion(buf[1024:]), nil
+	}
+
+	return "", errors.New("unknown filesystem")
+}
+
+// extVersion returns which of ext2, ext3 or ext4 the ext superblock sb is, from the features it uses.
+func extVersion(sb []byte) string {
+	compat := binary.LittleEndian.Uint32(sb[0x5c:])
+	incompat := binary.LittleEndian.Uint32(sb[0x60:])
+	roCompat := binary.LittleEndian.Uint32(sb[0x64:])
+
+	switch {
+	case incompat&^extFeatureIncompatExt3 != 0 || roCompat&^extFeatureROCompatExt3 != 0:
+		return "ext4"
+	case compat&extFeatureCompatHasJournal != 0:
+		return "ext3"
+	}
+
+	return "ext2"
+}
+
+// hasBytesAt returns true if buf contains want at offset.
+func hasBytesAt(buf []byte, offset int, want string) bool {
+	return len(buf) >= offset+len(want) && bytes.Equal(buf[offset:offset+len(want)], []byte(want))
+}
@@ -0,0 +1,98 @@
+package tpm_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+func TestProbeFilesystem(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fsType string
+		// truncateTo is the size the device is truncated to, if not 0.
+		truncateTo  int64
+		emptyDevice bool
+		noDevice    bool
+
+		wantErr bool
+	}{
+		"Detects_ext2": {fsType: "ext2"},
+		"Detects_ext3": {fsType: "ext3"},
+		"Detects_ext4": {fsType: "ext4"},
+		"Detects_xfs":  {fsType: "xfs"},
+		"Detects_vfat": {fsType: "vfat"},
+		"Detects_ntfs": {fsType: "ntfs"},
+
+		"Error_on_unknown_filesystem":    {fsType: "unknown", wantErr: true},
+		"Error_on_empty_device":          {fsType: "ext4", emptyDevice: true, wantErr: true},
+		"Error_on_truncated_superblock":  {fsType: "ext4", truncateTo: 1024 + 0x40, wantErr: true},
+		"Error_when_device_is_not_found": {noDevice: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			device := filepath.Join(t.TempDir(), "device")
+			if !tc.noDevice {
+				tpmtestutils.SetupFilesystem(is, device, tc.fsType)
+			}
+			if tc.truncateTo != 0 || tc.emptyDevice {
+				err := os.Truncate(device, tc.truncateTo)
+				is.NoErr(err) // Setup: could not truncate device
+			}
+
+			got, err := tpm.ProbeFilesystem(device)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.fsType) // ProbeFilesystem detects the filesystem type
+		})
+	}
+}
+
+func TestMountOptionNames(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "snap", "snapcraft.yaml"))
+	is.NoErr(err) // Setup: could not read snapcraft.yaml
+
+	var snapcraft struct {
+		Plugs map[string]struct {
+			Interface string `yaml:"interface"`
+			Mount     []struct {
+				Type    []string `yaml:"type"`
+				Options []string `yaml:"options"`
+			} `yaml:"mount"`
+		} `yaml:"plugs"`
+	}
+	err = yaml.Unmarshal(data, &snapcraft)
+	is.NoErr(err) // Setup: could not parse snapcraft.yaml
+
+	var entries int
+	for _, plug := range snapcraft.Plugs {
+		if plug.Interface != "mount-control" {
+			continue
+		}
+		for _, m := range plug.Mount {
+			entries++
+			is.Equal(m.Type, tpm.Filesystems)                                         // Mount entry allows the supported filesystems
+			is.Equal(slices.Sorted(slices.Values(m.Options)), tpm.MountOptionNames()) // Mount entry allows exactly the mount options
+		}
+	}
+	is.Equal(entries, 1) // Mount-control plug has a single mount entry
+
+	is.NoErr(tpm.ValidateMountOptions(tpm.MountOptions{Options: tpm.MountOptionNames()})) // Mount options are accepted together
+}
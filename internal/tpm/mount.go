@@ -1,13 +1,13 @@
 package tpm
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	_ "unsafe" // Needed for go:linkname.
 
 	"github.com/canonical/snap-tpmctl/internal/log"
@@ -20,132 +20,322 @@ import (
 var systemdCryptsetupPath string
 
 // Mount activates and mounts the TPM-protected volume at the given path to the target mount point.
-func (s SnapTPM) Mount(ctx context.Context, device, target string, authRequestor secboot.AuthRequestor) error {
-	if snapPath := os.Getenv("SNAP"); snapPath != "" {
-		systemdCryptsetupPath = filepath.Join(snapPath, "usr/bin/systemd-cryptsetup")
+// Volumes which were already activated by the tool and are not mounted are mounted as is, but not the ones mapped
+// by other tools. The volume is deactivated again if it was activated but could not be mounted.
+// The filesystem type is detected from the superblock of the volume, unless set in the options.
+// The target directory is created if needed, in an existing parent directory, and recorded to be removed when
+// unmounting.
+func (s SnapTPM) Mount(ctx context.Context, device, target string, opts MountOptions, authRequestor secboot.AuthRequestor) (err error) {
+	if err := opts.validate(); err != nil {
+		return err
 	}
 
-	// Check if the volume is active and mapped by other tools
-	p, err := s.getMapperFromDevice(device)
+	// Mount points are recorded and looked up as they are listed in the mount table.
+	target = filepath.Clean(target)
+
+	useSnapSystemdCryptsetup()
+
+	// A volume which was unlocked without being mounted, like with Activate, is mounted with its mapper.
+	volumeName := luksVolumeName(device)
+	mapperPath, err := s.getMapperFromDevice(device)
 	if err != nil {
-		return fmt.Errorf("unable to locate device: %v", err)
+		return fmt.Errorf("unable to locate device: %w", err)
 	}
-	if p != "" {
-		return fmt.Errorf("unable to activate device: resource is already mapped as %q", p)
+	if mapperPath == "" {
+		mapperPath = filepath.Join(s.root, "dev", "mapper", volumeName)
+	} else {
+		// Volumes mapped by other tools, like with crypttab, are theirs to mount and lock.
+		activated, err := s.isActivatedVolume(filepath.Base(mapperPath))
+		if err != nil {
+			return err
+		}
+		if _, statErr := os.Stat(mapperPath); !activated || statErr != nil {
+			return fmt.Errorf("unable to activate device: %w as %q", ErrAlreadyMapped, mapperPath)
+		}
 	}
 
-	volumeName := luksVolumeName(device)
-	mapperPath := filepath.Join(s.root, "dev", "mapper", volumeName)
-
-	if err := os.MkdirAll(target, 0750); err != nil {
-		return fmt.Errorf("unable to create directory: %v", err)
+	// Check if the volume is already mounted
+	mounts, err := s.mountsOf(mapperPath)
+	if err != nil {
+		return fmt.Errorf("unable to locate volume: %w", err)
+	}
+	if len(mounts) > 0 {
+		return fmt.Errorf("unable to activate volume: %w as %q", ErrAlreadyMounted, mounts[0].MountPoint)
 	}
 
-	// Check if the volume is already mounted by the tool
-	p, err = s.getMountFromMapper(mapperPath)
+	created, err := s.createMountPoint(target)
 	if err != nil {
-		return fmt.Errorf("unable to locate volume: %v", err)
+		return err
 	}
-	if p != "" {
-		return fmt.Errorf("unable to activate volume: resource is already mounted as %q", p)
+	if created {
+		// Don't leave behind a mount point which was created for nothing.
+		defer func() {
+			if err == nil {
+				return
+			}
+			if e := s.removeMountPoint(ctx, target); e != nil {
+				log.Warn(ctx, "Could not remove mount point %q: %v", target, e)
+			}
+		}()
 	}
 
 	// Check if volume is already active
-	if _, err := os.Stat(mapperPath); os.IsNotExist(err) {
-		if err := secboot.ActivateVolumeWithRecoveryKey(
-			volumeName,
-			device,
-			authRequestor,
-			&secboot.ActivateVolumeOptions{
-				RecoveryKeyTries: 3,
-			}); err != nil {
-			return fmt.Errorf("unable to activate volume: %v", err)
+	if _, statErr := os.Stat(mapperPath); os.IsNotExist(statErr) {
+		if err := s.activate(ctx, volumeName, device, opts.Auth, authRequestor); err != nil {
+			return fmt.Errorf("unable to activate volume: %w", err)
+		}
+
+		// Don't leave unlocked a volume which was unlocked for nothing.
+		defer func() {
+			if err == nil {
+				return
+			}
+			if e := s.deactivateVolume(volumeName); e != nil {
+				log.Warn(ctx, "Could not deactivate volume %q: %v", volumeName, e)
+			}
+		}()
+
+		if err := s.recordActivatedVolume(volumeName); err != nil {
+			return err
 		}
 	}
 
-	log.Debug(ctx, "Mounting %q to %q", mapperPath, target)
-	if err := s.syscall.Mount(mapperPath, target); err != nil {
-		return fmt.Errorf("unable to mount volume: %v", err)
+	fsType := opts.FSType
+	if fsType == "" {
+		fsType, err = probeFilesystem(mapperPath)
+		if err != nil {
+			return fmt.Errorf("unable to detect filesystem type, set it explicitly: %w", err)
+		}
+	}
+
+	log.Debug(ctx, "Mounting %q to %q as %s", mapperPath, target, fsType)
+	if err := s.syscall.Mount(mapperPath, target, fsType, opts.flags()); err != nil {
+		return fmt.Errorf("unable to mount volume: %w", err)
 	}
 
 	return nil
 }
 
 // Unmount unmounts and deactivate the TPM-protected volume from the target mount point.
+// The mount point is only removed if the tool created it and it is empty, once it is no longer mounted.
 func (s SnapTPM) Unmount(ctx context.Context, target string) error {
-	if snapPath := os.Getenv("SNAP"); snapPath != "" {
-		systemdCryptsetupPath = filepath.Join(snapPath, "usr/bin/systemd-cryptsetup")
+	useSnapSystemdCryptsetup()
+
+	// Mount points are listed cleaned in the mount table, like /mnt/x for /mnt//x/.
+	target = filepath.Clean(target)
+
+	m, ok, err := s.mountAt(target)
+	if err != nil {
+		return fmt.Errorf("unable to determine device path: %w", err)
+	}
+	if !ok {
+		return ErrNotMounted
 	}
+	mapperPath := m.Source
 
-	mapperPath, err := s.getMapperFromMount(target)
+	// The volume can only be locked once it is not used anymore, like by bind mounts of its directories.
+	others, err := s.otherMountsOf(m)
 	if err != nil {
-		return fmt.Errorf("unable to determine device path: %v", err)
+		return fmt.Errorf("unable to locate volume: %w", err)
 	}
-	if mapperPath == "" {
-		return errors.New("path not found in /proc/mounts")
+	if len(others) > 0 {
+		what := "is also mounted"
+		if others[0].isBindMount() {
+			what = fmt.Sprintf("has %q bind mounted", others[0].Root)
+		}
+		return fmt.Errorf("unable to unmount volume: %w: %q %s on %q, unmount it first",
+			ErrAlreadyMounted, mapperPath, what, others[0].MountPoint)
 	}
 
 	if err := s.syscall.Unmount(target); err != nil {
-		return fmt.Errorf("unable to unmount volume: %v", err)
+		return fmt.Errorf("unable to unmount volume: %w", err)
 	}
 
-	if err := os.RemoveAll(target); err != nil {
-		return fmt.Errorf("unable to remove mount point: %v", err)
+	// The directory must not be touched if anything is still mounted on it, like another mount stacked below.
+	below, ok, err := s.mountAt(target)
+	if err != nil {
+		return fmt.Errorf("unable to check mount point: %w", err)
+	}
+	if ok {
+		return fmt.Errorf("unable to unmount volume: %w: %q is still mounted on %q", ErrAlreadyMounted, below.Source, target)
 	}
 
-	volumeName := filepath.Base(mapperPath)
-	if err := secboot.DeactivateVolume(volumeName); err != nil {
-		return fmt.Errorf("unable to deactivate volume: %v", err)
+	if err := s.removeMountPoint(ctx, target); err != nil {
+		return err
 	}
 
-	return nil
+	return s.deactivateVolume(filepath.Base(mapperPath))
 }
 
-type mountsFieldType int
+// createMountPoint creates the target directory if it does not exist, and records it to be removed when unmounting.
+// It returns true if the directory was created.
+func (s SnapTPM) createMountPoint(target string) (bool, error) {
+	if info, err := os.Stat(target); err == nil {
+		if !info.IsDir() {
+			return false, fmt.Errorf("unable to create directory: %q is not a directory", target)
+		}
+		return false, nil
+	}
 
-const (
-	// deviceField is the field index for the device in /proc/mounts.
-	deviceField mountsFieldType = iota
-	// mountPointField is the field index for the mount point in /proc/mounts.
-	mountPointField
-)
+	// Only the mount point itself is created, so that it is the only directory to remove when unmounting.
+	if err := os.Mkdir(target, 0750); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("unable to create directory: parent directory of %q does not exist", target)
+		}
+		return false, fmt.Errorf("unable to create directory: %w", err)
+	}
 
-// getMapperFromMount parses /proc/mounts and returns the mapper path for the given mount point.
-func (s SnapTPM) getMapperFromMount(mountPoint string) (string, error) {
-	return s.searchInProcMounts(mountPoint, mountPointField, deviceField)
+	if err := s.recordCreatedMountPoint(target); err != nil {
+		// Without its record, the directory would never be removed.
+		_ = os.Remove(target)
+		return false, err
+	}
+
+	return true, nil
 }
 
-// mountFromMapper parses /proc/mounts and returns the mapper path for the given mount point.
-func (s SnapTPM) getMountFromMapper(mapperPath string) (string, error) {
-	return s.searchInProcMounts(mapperPath, deviceField, mountPointField)
+// removeMountPoint removes the target directory if the tool created it, unless it contains anything,
+// and forgets about it. Directories which were not created by the tool are left untouched.
+func (s SnapTPM) removeMountPoint(ctx context.Context, target string) error {
+	created, err := s.isCreatedMountPoint(target)
+	if err != nil {
+		return err
+	}
+	if !created {
+		log.Debug(ctx, "Keeping mount point %q, which was not created by snap-tpmctl", target)
+		return nil
+	}
+
+	// Only remove the directory itself: files could have been written in it while it was not mounted.
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		if !errors.Is(err, syscall.ENOTEMPTY) && !errors.Is(err, syscall.EEXIST) {
+			return fmt.Errorf("unable to remove mount point: %w", err)
+		}
+		log.Warn(ctx, "Keeping mount point %q, which is not empty", target)
+	}
+
+	return s.forgetCreatedMountPoint(target)
 }
 
-// searchInProcMounts searches /proc/mounts for a specific path in the specified field and returns the corresponding field result.
-func (s SnapTPM) searchInProcMounts(path string, fieldPath, fieldResult mountsFieldType) (string, error) {
-	file, err := os.Open(filepath.Join(s.root, "proc", "mounts"))
+// Activate unlocks the TPM-protected volume on device without mounting it, and returns the path of the decrypted
+// block device. The volume is mapped as name, or as a name derived from the device path when empty, and recorded
+// so that Mount can mount it later.
+func (s SnapTPM) Activate(ctx context.Context, device, name string, auth UnlockAuth, authRequestor secboot.AuthRequestor) (string, error) {
+	if err := validateUnlockAuth(auth); err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		name = luksVolumeName(device)
+	}
+	if strings.Contains(name, "/") || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid volume name %q", name)
+	}
+
+	useSnapSystemdCryptsetup()
+
+	// Check if the volume is active and mapped by this tool or other ones
+	p, err := s.getMapperFromDevice(device)
 	if err != nil {
-		return "", fmt.Errorf("unable to open /proc/mounts: %v", err)
+		return "", fmt.Errorf("unable to locate device: %w", err)
+	}
+	if p != "" {
+		return "", fmt.Errorf("unable to activate device: %w as %q", ErrAlreadyMapped, p)
+	}
+
+	mapperPath := filepath.Join(s.root, "dev", "mapper", name)
+	if _, err := os.Stat(mapperPath); err == nil {
+		return "", fmt.Errorf("unable to activate device: %w: %q is used by another volume", ErrAlreadyMapped, mapperPath)
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		fields := strings.Fields(scanner.Text())
-		// Each line format: device mount_point fstype options dummy dummy
-		if len(fields) < 2 {
-			continue
+	log.Debug(ctx, "Activating %q as %q", device, name)
+	if err := s.activate(ctx, name, device, auth, authRequestor); err != nil {
+		return "", fmt.Errorf("unable to activate volume: %w", err)
+	}
+
+	if err := s.recordActivatedVolume(name); err != nil {
+		// Without its record, the volume could not be mounted.
+		if e := secboot.DeactivateVolume(name); e != nil {
+			log.Warn(ctx, "Could not deactivate volume %q: %v", name, e)
 		}
+		return "", err
+	}
 
-		if fields[fieldPath] == path {
-			return fields[fieldResult], nil
+	return mapperPath, nil
+}
+
+// Deactivate locks the TPM-protected volume, given by its mapper name or path, or by its encrypted device.
+// Mounted volumes are not deactivated. It returns the path of the mapper which was removed.
+func (s SnapTPM) Deactivate(ctx context.Context, volume string) (string, error) {
+	useSnapSystemdCryptsetup()
+
+	mapperPath, err := s.resolveMapper(volume)
+	if err != nil {
+		return "", err
+	}
+
+	// Check if the volume is still in use
+	mounts, err := s.mountsOf(mapperPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to locate volume: %w", err)
+	}
+	if len(mounts) > 0 {
+		return "", fmt.Errorf("unable to deactivate volume: %w on %q, unmount it first", ErrAlreadyMounted, mounts[0].MountPoint)
+	}
+
+	log.Debug(ctx, "Deactivating %q", mapperPath)
+	if err := s.deactivateVolume(filepath.Base(mapperPath)); err != nil {
+		return "", err
+	}
+
+	return mapperPath, nil
+}
+
+// deactivateVolume locks the volume mapped as volumeName, and forgets that the tool activated it.
+func (s SnapTPM) deactivateVolume(volumeName string) error {
+	if err := secboot.DeactivateVolume(volumeName); err != nil {
+		return fmt.Errorf("unable to deactivate volume: %w", err)
+	}
+
+	return s.forgetActivatedVolume(volumeName)
+}
+
+// resolveMapper returns the mapper path of volume, which is either a mapper name, a mapper path,
+// or an encrypted device which is mapped.
+func (s SnapTPM) resolveMapper(volume string) (string, error) {
+	mapperDir := filepath.Join(s.root, "dev", "mapper")
+
+	var mapperPath string
+	switch {
+	case volume == "":
+		return "", errors.New("volume cannot be empty")
+	case !strings.Contains(volume, "/"):
+		mapperPath = filepath.Join(mapperDir, volume)
+	case filepath.Dir(volume) == "/dev/mapper" || filepath.Dir(volume) == mapperDir:
+		mapperPath = filepath.Join(mapperDir, filepath.Base(volume))
+	default:
+		p, err := s.getMapperFromDevice(volume)
+		if err != nil {
+			return "", fmt.Errorf("unable to locate device: %w", err)
+		}
+		if p == "" {
+			return "", fmt.Errorf("%w: %q is not mapped", ErrNotActive, volume)
 		}
+		mapperPath = p
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("error reading /proc/mounts: %v", err)
+	if _, err := os.Stat(mapperPath); err != nil {
+		return "", fmt.Errorf("%w: %q does not exist", ErrNotActive, mapperPath)
 	}
 
-	return "", nil
+	return mapperPath, nil
+}
+
+// useSnapSystemdCryptsetup makes secboot run the systemd-cryptsetup shipped with the snap, when running from it.
+func useSnapSystemdCryptsetup() {
+	if snapPath := os.Getenv("SNAP"); snapPath != "" {
+		systemdCryptsetupPath = filepath.Join(snapPath, "usr/bin/systemd-cryptsetup")
+	}
 }
 
 // getMapperFromDevice checks if a device is already mapped by other tools by reading /sys/class/block/holders.
@@ -154,7 +344,7 @@ func (s SnapTPM) getMapperFromDevice(device string) (string, error) {
 	holdersPath := filepath.Join(s.root, "sys", "class", "block", filepath.Base(device), "holders")
 	holders, err := os.ReadDir(holdersPath)
 	if err != nil {
-		return "", fmt.Errorf("unable to read holders: %v", err)
+		return "", fmt.Errorf("unable to read holders: %w", err)
 	}
 
 	if len(holders) == 0 {
@@ -165,7 +355,7 @@ func (s SnapTPM) getMapperFromDevice(device string) (string, error) {
 	dmNamePath := filepath.Join(s.root, "sys", "class", "block", holders[0].Name(), "dm", "name")
 	mapperName, err := os.ReadFile(dmNamePath)
 	if err != nil {
-		return "", fmt.Errorf("unable to read mapper: %v", err)
+		return "", fmt.Errorf("unable to read mapper: %w", err)
 	}
 
 	return filepath.Join(s.root, "dev", "mapper", strings.TrimSpace(string(mapperName))), nil
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/canonical/snap-tpmctl/internal/testutils"
@@ -20,20 +21,34 @@ func TestMountVolume(t *testing.T) {
 	tests := map[string]struct {
 		device        string
 		target        string
+		opts          tpm.MountOptions
+		fsType        string
 		syscall       tpmtestutils.TestSyscall
+		activator     tpmtestutils.TestActivator
 		authRequestor authRequestor
 
 		targetExists      bool
 		mkdirErr          bool
+		parentMissing     bool
 		alreadyMountedErr bool
 		deviceInUse       bool
-		readErr           bool
-		classBlockErr     bool
+		// activated maps the device as mapper-name beforehand, like Activate, and activatedMounted mounts it.
+		activated        bool
+		activatedMounted bool
+		// foreignMapper maps the device as mapper-name beforehand, like another tool.
+		foreignMapper bool
+		readErr       bool
+		classBlockErr bool
 
-		wantMounted   bool
-		wantRequested bool
+		wantMounted     bool
+		wantRequested   bool
+		wantDeactivated bool
+		wantFSType      string
+		wantFlags       uintptr
+		wantTries       tpm.UnlockTries
 
-		wantErr bool
+		wantErr   bool
+		wantErrIs error
 	}{
 		"Success on mounting volume": {wantRequested: true, wantMounted: true},
 		"Success when target already exists": {
@@ -42,15 +57,75 @@ func TestMountVolume(t *testing.T) {
 			wantRequested: true,
 			wantMounted:   true,
 		},
+		"Success detecting xfs filesystem": {fsType: "xfs", wantRequested: true, wantMounted: true, wantFSType: "xfs"},
+		"Success with explicit filesystem type": {
+			opts:          tpm.MountOptions{FSType: "vfat"},
+			fsType:        "unknown",
+			wantRequested: true,
+			wantMounted:   true,
+			wantFSType:    "vfat",
+		},
+		"Success with mount options": {
+			opts:          tpm.MountOptions{Options: []string{"rw", "relatime"}},
+			wantRequested: true,
+			wantMounted:   true,
+			wantFlags:     syscall.MS_RELATIME,
+		},
+		"Success unlocking with passphrase": {
+			opts:          tpm.MountOptions{Auth: tpm.UnlockAuthPassphrase},
+			activator:     tpmtestutils.TestActivator{Credential: testCredential},
+			wantRequested: true,
+			wantMounted:   true,
+			wantTries:     tpm.UnlockTries{Passphrase: tpm.MaxUnlockTries},
+		},
+		"Success unlocking with PIN": {
+			opts:          tpm.MountOptions{Auth: tpm.UnlockAuthPIN},
+			activator:     tpmtestutils.TestActivator{Credential: testCredential},
+			wantRequested: true,
+			wantMounted:   true,
+			wantTries:     tpm.UnlockTries{PIN: tpm.MaxUnlockTries},
+		},
+		"Success unlocking with any credential": {
+			opts:          tpm.MountOptions{Auth: tpm.UnlockAuthAuto},
+			activator:     tpmtestutils.TestActivator{Credential: testCredential},
+			wantRequested: true,
+			wantMounted:   true,
+			wantTries:     tpm.UnlockTries{Passphrase: tpm.MaxUnlockTries, PIN: tpm.MaxUnlockTries, RecoveryKey: tpm.MaxUnlockTries},
+		},
+		"Success mounting volume which is already active": {activated: true, wantMounted: true},
 
-		"Error when unable to create directory":               {mkdirErr: true, wantErr: true},
-		"Error when authRequestor fails":                      {authRequestor: authRequestor{wantErr: true}, wantErr: true},
-		"Error when unable to mount volume":                   {syscall: tpmtestutils.TestSyscall{WantErr: true}, wantRequested: true, wantErr: true},
-		"Error when volume is already mounted":                {alreadyMountedErr: true, wantErr: true},
-		"Error when unable to locate volume":                  {readErr: true, wantErr: true},
-		"Error when systemd cryptsetup fails":                 {device: "exit-with-failure", wantRequested: true, wantErr: true},
-		"Error when device is already in use by another tool": {deviceInUse: true, wantErr: true},
-		"Error when device cannot be located":                 {deviceInUse: true, classBlockErr: true, wantErr: true},
+		"Error when unable to create directory": {mkdirErr: true, wantErr: true},
+		"Error when parent of target does not exist": {
+			target:        "missing-parent/mount-dir",
+			parentMissing: true,
+			wantErr:       true,
+		},
+		"Error when authRequestor fails":    {authRequestor: authRequestor{wantErr: true}, wantErr: true},
+		"Error when unable to mount volume": {syscall: tpmtestutils.TestSyscall{WantErr: true}, wantRequested: true, wantDeactivated: true, wantErr: true},
+		"Error when unable to mount volume which is already active": {
+			activated: true,
+			syscall:   tpmtestutils.TestSyscall{WantErr: true},
+			wantErr:   true,
+		},
+		"Error when volume which is already active is mounted": {activated: true, activatedMounted: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted},
+		"Error when volume is already mounted":                 {alreadyMountedErr: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted},
+		"Error when unable to locate volume":                   {readErr: true, wantErr: true},
+		"Error when systemd cryptsetup fails":                  {device: "exit-with-failure", wantRequested: true, wantErr: true},
+		"Error when device is already in use by another tool":  {deviceInUse: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMapped},
+		"Error when device is already mapped by another tool":  {foreignMapper: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMapped},
+		"Error when device cannot be located":                  {deviceInUse: true, classBlockErr: true, wantErr: true},
+		"Error when filesystem is unknown":                     {fsType: "unknown", wantRequested: true, wantDeactivated: true, wantErr: true},
+		"Error on unsupported filesystem type":                 {opts: tpm.MountOptions{FSType: "btrfs"}, wantErr: true},
+		"Error on unsupported mount option":                    {opts: tpm.MountOptions{Options: []string{"sync"}}, wantErr: true},
+		"Error on read-only mount":                             {opts: tpm.MountOptions{ReadOnly: true}, wantErr: true},
+		"Error on mount options not allowed by the snap":       {opts: tpm.MountOptions{Options: []string{"noatime"}}, wantErr: true},
+		"Error on unsupported unlock authentication":           {opts: tpm.MountOptions{Auth: "fingerprint"}, wantErr: true},
+		"Error when no tries are left": {
+			opts:          tpm.MountOptions{Auth: tpm.UnlockAuthPIN},
+			activator:     tpmtestutils.TestActivator{Credential: "other"},
+			wantRequested: true,
+			wantErr:       true,
+		},
 	}
 
 	for name, tc := range tests {
@@ -63,18 +138,33 @@ func TestMountVolume(t *testing.T) {
 			// cryptsetup mock binary
 			tpmtestutils.SetupMockBinary(is, root)
 			t.Setenv("SNAP", root)
+			t.Setenv("SNAP_DATA", "")
 
 			if tc.device == "" {
 				tc.device = "test-device"
 			}
 			tc.device = filepath.Join(root, tc.device) // Convert to an absolute path
 
+			if tc.fsType == "" {
+				tc.fsType = "ext4"
+			}
+			tpmtestutils.SetupFilesystem(is, tc.device, tc.fsType)
+
 			if tc.target == "" {
 				tc.target = "mount-dir"
 			}
 			tc.target = filepath.Join(root, tc.target) // Convert to an absolute path
 
+			mapperPath := filepath.Join(root, "dev/mapper", tpmtestutils.LuksVolumeName(tc.device))
+			if tc.activated || tc.foreignMapper {
+				mapperPath = filepath.Join(root, "dev/mapper", "mapper-name")
+				tpmtestutils.SetupFilesystem(is, mapperPath, tc.fsType)
+			}
+
 			content := ""
+			if tc.activatedMounted {
+				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", mapperPath, filepath.Join(root, "elsewhere"))
+			}
 			if tc.alreadyMountedErr {
 				mapper := filepath.Join(root, "dev/mapper", tpmtestutils.LuksVolumeName(tc.device))
 				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", mapper, tc.target)
@@ -84,10 +174,10 @@ func TestMountVolume(t *testing.T) {
 				content = strings.Repeat("a", 70*1024) + "\n"
 			}
 
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 
 			if !tc.classBlockErr {
-				tpmtestutils.SetupSysClassBlock(is, root, tc.device, tc.deviceInUse)
+				tpmtestutils.SetupSysClassBlock(is, root, tc.device, tc.deviceInUse || tc.activated || tc.foreignMapper)
 			}
 
 			if tc.targetExists {
@@ -105,38 +195,126 @@ func TestMountVolume(t *testing.T) {
 			s := tpm.New(
 				tpmtestutils.WithRoot(root),
 				tpmtestutils.WithSyscall(&tc.syscall),
+				tpmtestutils.WithActivator(&tc.activator),
 			)
 
-			err := s.Mount(ctx, tc.device, tc.target, &tc.authRequestor)
+			if tc.activated {
+				err := tpm.RecordActivatedVolume(s, "mapper-name")
+				is.NoErr(err) // Setup: volume should be recorded as activated by the tool
+			}
+			err := s.Mount(ctx, tc.device, tc.target, tc.opts, &tc.authRequestor)
+			if tc.wantErrIs != nil {
+				is.True(errors.Is(err, tc.wantErrIs)) // Mount returns the expected error
+			}
+
+			created, stateErr := tpm.IsCreatedMountPoint(s, tc.target)
+			is.NoErr(stateErr)                                // the mount points state is readable
+			is.Equal(created, err == nil && !tc.targetExists) // only the mount points created for the volume are recorded
+
+			activated, stateErr := tpm.IsActivatedVolume(s, filepath.Base(mapperPath))
+			is.NoErr(stateErr)                                                   // the mount points state is readable
+			is.Equal(activated, tc.activated || err == nil && !tc.foreignMapper) // only the volumes activated by the tool are recorded
+
 			if testutils.CheckError(is, err, tc.wantErr) {
+				if !tc.targetExists && !tc.mkdirErr {
+					_, statErr := os.Stat(tc.target)
+					is.True(errors.Is(statErr, os.ErrNotExist)) // the mount point created for nothing is removed
+				}
+				if tc.parentMissing {
+					_, statErr := os.Stat(filepath.Dir(tc.target))
+					is.True(errors.Is(statErr, os.ErrNotExist)) // the missing parent directory is not created
+				}
+				if tc.activated || tc.foreignMapper {
+					_, statErr := os.Stat(mapperPath)
+					is.NoErr(statErr) // the volume which was already active is left active
+				}
+				if tc.wantDeactivated {
+					_, statErr := os.Stat(mapperPath)
+					is.True(errors.Is(statErr, os.ErrNotExist)) // the volume activated for nothing is deactivated
+				}
 				return
 			}
 
 			is.Equal(tc.authRequestor.requested, tc.wantRequested) // the recovery key is asked as expected
 			is.Equal(tc.syscall.Mounted, tc.wantMounted)           // the volume is mounted as expected
+			is.Equal(tc.activator.Tries, tc.wantTries)             // the volume is unlocked with the expected credentials
+
+			if tc.wantFSType == "" {
+				tc.wantFSType = "ext4"
+			}
+			if tc.wantFlags == 0 {
+				tc.wantFlags = syscall.MS_RELATIME
+			}
+			is.Equal(tc.syscall.Source, mapperPath)    // the volume is mounted from its mapper
+			is.Equal(tc.syscall.FSType, tc.wantFSType) // the volume is mounted with the expected filesystem type
+			is.Equal(tc.syscall.Flags, tc.wantFlags)   // the volume is mounted with the expected flags
+
+			_, statErr := os.Stat(mapperPath)
+			is.NoErr(statErr) // the mounted volume is left active
 		})
 	}
 }
 
 func TestUnmountVolume(t *testing.T) {
+	// The mapper of the volume is mounted on mount-dir by default. Bind mounts are written in the mountinfo format,
+	// with the device number given to the mapper.
+	const (
+		defaultMounts = "<mapper> <root>/mount-dir ext4 rw 0 0\n"
+		bindMount     = "200 1 253:0 /photos <root>/pictures rw - ext4 <mapper> rw\n"
+	)
+
 	tests := map[string]struct {
-		target  string
-		mapper  string
-		syscall tpmtestutils.TestSyscall
+		target string
+		// unmountAs is the path of the target as given to Unmount, relative to the root, when not the cleaned one.
+		unmountAs string
+		mapper    string
+		mounts    string
+		syscall   tpmtestutils.TestSyscall
 
-		readErr       bool
-		wantUnmounted bool
+		created      bool
+		notEmpty     bool
+		invalidState bool
+		readErr      bool
+
+		wantUnmounted  bool
+		wantDirRemoved bool
 
 		wantErr      bool
+		wantErrIs    error
 		wantRmdirErr bool
 	}{
-		"Success on unmounting volume": {wantUnmounted: true},
+		"Success on unmounting volume":                     {wantUnmounted: true},
+		"Success removing mount point created by the tool": {created: true, wantUnmounted: true, wantDirRemoved: true},
+		"Success keeping created mount point not empty":    {created: true, notEmpty: true, wantUnmounted: true},
+		"Success with escaped mount point": {
+			target:        "my mount dir",
+			mounts:        "<mapper> <root>/my\\040mount\\040dir ext4 rw 0 0\n",
+			created:       true,
+			wantUnmounted: true, wantDirRemoved: true,
+		},
+		"Success with trailing slash":   {unmountAs: "mount-dir/", created: true, wantUnmounted: true, wantDirRemoved: true},
+		"Success with repeated slashes": {unmountAs: "/mount-dir", created: true, wantUnmounted: true, wantDirRemoved: true},
+		"Success with other volumes mounted": {
+			mounts:        "<root>/dev/mapper/other <root>/other-dir ext4 rw 0 0\n" + defaultMounts,
+			wantUnmounted: true,
+		},
 
-		"Error when unable to remove directory":      {wantRmdirErr: true, wantErr: true},
+		"Error when unable to remove directory":      {created: true, wantRmdirErr: true, wantErr: true},
 		"Error when unable to determine device path": {readErr: true, wantErr: true},
-		"Error when path is not found":               {target: "not-existing-target", wantErr: true},
+		"Error when path is not found":               {target: "not-existing-target", wantErr: true, wantErrIs: tpm.ErrNotMounted},
 		"Error when unable to unmount volume":        {syscall: tpmtestutils.TestSyscall{WantErr: true}, wantErr: true},
-		"Error when systemd cryptsetup fails":        {mapper: "exit-with-failure", wantErr: true},
+		"Error when target is still mounted":         {created: true, syscall: tpmtestutils.TestSyscall{KeepMounted: true}, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted},
+		"Error when another mount is stacked below": {
+			mounts:  "<root>/dev/mapper/other <root>/mount-dir ext4 rw 0 0\n" + defaultMounts,
+			created: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted,
+		},
+		"Error when volume is also mounted elsewhere": {
+			mounts:  defaultMounts + "<mapper> <root>/other-dir ext4 rw 0 0\n",
+			wantErr: true, wantErrIs: tpm.ErrAlreadyMounted,
+		},
+		"Error when directory of volume is bind mounted": {mounts: defaultMounts + bindMount, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted},
+		"Error when mount points state is invalid":       {created: true, invalidState: true, wantErr: true},
+		"Error when systemd cryptsetup fails":            {mapper: "exit-with-failure", wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -149,30 +327,55 @@ func TestUnmountVolume(t *testing.T) {
 			// cryptsetup mock binary
 			tpmtestutils.SetupMockBinary(is, root)
 			t.Setenv("SNAP", root)
+			t.Setenv("SNAP_DATA", "")
 
 			if tc.mapper == "" {
 				tc.mapper = "test-device"
 			}
 			tc.mapper = filepath.Join(root, "dev", "mapper", tc.mapper) // Convert to an absolute path
 
-			target := "mount-dir"
 			if tc.target == "" {
-				tc.target = target
+				tc.target = "mount-dir"
 			}
 			tc.target = filepath.Join(root, tc.target) // Convert to an absolute path
 
-			content := fmt.Sprintf("%s %s ext4 rw 0 0\n", tc.mapper, filepath.Join(root, target))
+			if tc.mounts == "" {
+				tc.mounts = defaultMounts
+			}
+			content := strings.NewReplacer("<mapper>", tc.mapper, "<root>", root).Replace(tc.mounts)
 			if tc.readErr {
 				// Scanner default max token: 64K. This will return a read error
 				content = strings.Repeat("a", 70*1024) + "\n"
 			}
-			tpmtestutils.SetupProcMount(is, root, content)
+			tpmtestutils.SetupMountInfo(is, root, content)
 
-			// In order to test the `RemoveAll` failure, we need to set restrictive permissions for the target's parent folder.
-			if tc.wantRmdirErr {
-				err := os.MkdirAll(tc.target, 0750)
-				is.NoErr(err)
+			err := os.MkdirAll(tc.target, 0750)
+			is.NoErr(err) // Setup: target directory should exist before unmounting
+
+			tc.syscall.Root = root
+			s := tpm.New(
+				tpmtestutils.WithRoot(root),
+				tpmtestutils.WithSyscall(&tc.syscall),
+			)
+
+			if tc.created {
+				err := tpm.RecordCreatedMountPoint(s, tc.target)
+				is.NoErr(err) // Setup: target should be recorded as created by the tool
+			}
+			err = tpm.RecordActivatedVolume(s, filepath.Base(tc.mapper))
+			is.NoErr(err) // Setup: volume should be recorded as activated by the tool
+			if tc.invalidState {
+				err := os.WriteFile(filepath.Join(root, "var", "lib", "snap-tpmctl", "mount-points.json"), []byte("invalid"), 0600)
+				is.NoErr(err) // Setup: mount points state should be invalid
+			}
+			if tc.notEmpty {
+				// Files written while the volume was not mounted.
+				err := os.WriteFile(filepath.Join(tc.target, "data"), []byte("data"), 0600)
+				is.NoErr(err) // Setup: target should contain a file
+			}
 
+			// In order to test the `Remove` failure, we need to set restrictive permissions for the target's parent folder.
+			if tc.wantRmdirErr {
 				//nolint:gosec // test-only permissions, non-sensitive temp path
 				err = os.Chmod(filepath.Dir(tc.target), 0555)
 				is.NoErr(err)
@@ -184,97 +387,207 @@ func TestUnmountVolume(t *testing.T) {
 				}()
 			}
 
-			s := tpm.New(
-				tpmtestutils.WithRoot(root),
-				tpmtestutils.WithSyscall(&tc.syscall),
-			)
+			unmountTarget := tc.target
+			if tc.unmountAs != "" {
+				unmountTarget = root + "/" + tc.unmountAs
+			}
 
-			err := s.Unmount(ctx, tc.target)
+			err = s.Unmount(ctx, unmountTarget)
+			if tc.wantErrIs != nil {
+				is.True(errors.Is(err, tc.wantErrIs)) // Unmount returns the expected error
+			}
 			if testutils.CheckError(is, err, tc.wantErr) {
+				_, statErr := os.Stat(tc.target)
+				is.NoErr(statErr) // the mount point is kept when unmounting fails
 				return
 			}
 
 			is.Equal(tc.syscall.Unmounted, tc.wantUnmounted) // the volume is unmounted as expected
+
+			_, statErr := os.Stat(tc.target)
+			is.Equal(errors.Is(statErr, os.ErrNotExist), tc.wantDirRemoved) // only empty mount points created by the tool are removed
+
+			created, err := tpm.IsCreatedMountPoint(s, tc.target)
+			is.NoErr(err)     // the mount points state is readable
+			is.True(!created) // the mount point is forgotten once unmounted
+
+			activated, err := tpm.IsActivatedVolume(s, filepath.Base(tc.mapper))
+			is.NoErr(err)       // the mount points state is readable
+			is.True(!activated) // the volume is forgotten once deactivated
 		})
 	}
 }
 
-func TestSearchInProcMounts(t *testing.T) {
-	t.Parallel()
-
+func TestActivateVolume(t *testing.T) {
 	tests := map[string]struct {
-		path        string
-		fieldPath   tpm.MountsFiledType
-		fieldResult tpm.MountsFiledType
+		device    string
+		name      string
+		auth      tpm.UnlockAuth
+		activator tpmtestutils.TestActivator
 
-		content  string
-		notFound bool
-		fileErr  bool
-		readErr  bool
+		deviceInUse   bool
+		mapperExists  bool
+		classBlockErr bool
 
-		wantErr bool
-	}{
-		"Success on getting mount":                   {fieldPath: 1, fieldResult: 0},
-		"Success on getting mapper":                  {fieldPath: 0, fieldResult: 1},
-		"Success with no path found in /proc/mounts": {path: "wrong-path", notFound: true},
+		wantMapper string
 
-		"Error opening /proc/mounts": {fileErr: true, wantErr: true},
-		"Error reading /proc/mounts": {readErr: true, wantErr: true},
+		wantErr   bool
+		wantErrIs error
+	}{
+		"Success on activating volume":      {wantMapper: "<device>"},
+		"Success with volume name":          {name: "rescued", wantMapper: "rescued"},
+		"Success unlocking with passphrase": {auth: tpm.UnlockAuthPassphrase, activator: tpmtestutils.TestActivator{Credential: testCredential}, wantMapper: "<device>"},
+
+		"Error on unsupported unlock authentication":          {auth: "fingerprint", wantErr: true},
+		"Error on invalid volume name":                        {name: "../rescued", wantErr: true},
+		"Error when device is already in use by another tool": {deviceInUse: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMapped},
+		"Error when volume name is already used":              {name: "rescued", mapperExists: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMapped},
+		"Error when device cannot be located":                 {classBlockErr: true, wantErr: true},
+		"Error when systemd cryptsetup fails":                 {device: "exit-with-failure", wantErr: true},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			t.Parallel()
 			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
 
 			root := t.TempDir()
 
-			mapper := filepath.Join(root, "dev", "mapper", "test-device") // Convert to an absolute path
-			mount := filepath.Join(root, "mount-dir")                     // Convert to an absolute path
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			t.Setenv("SNAP", root)
+
+			if tc.device == "" {
+				tc.device = "test-device"
+			}
+			tc.device = filepath.Join(root, tc.device) // Convert to an absolute path
+			tpmtestutils.SetupFilesystem(is, tc.device, "ext4")
 
-			if tc.path == "" {
-				switch tc.fieldPath {
-				case 0:
-					tc.path = mapper
-				case 1:
-					tc.path = mount
+			if !tc.classBlockErr {
+				tpmtestutils.SetupSysClassBlock(is, root, tc.device, tc.deviceInUse)
+			}
+
+			if tc.mapperExists {
+				err := os.MkdirAll(filepath.Join(root, "dev", "mapper"), 0750)
+				is.NoErr(err) // Setup: mapper directory should exist
+				err = os.WriteFile(filepath.Join(root, "dev", "mapper", tc.name), nil, 0600)
+				is.NoErr(err) // Setup: mapper of another volume should exist
+			}
+
+			s := tpm.New(
+				tpmtestutils.WithRoot(root),
+				tpmtestutils.WithActivator(&tc.activator),
+			)
+
+			got, err := s.Activate(ctx, tc.device, tc.name, tc.auth, &authRequestor{})
+			if tc.wantErrIs != nil {
+				is.True(errors.Is(err, tc.wantErrIs)) // Activate returns the expected error
+			}
+			if testutils.CheckError(is, err, tc.wantErr) {
+				volumeName := tc.name
+				if volumeName == "" {
+					volumeName = tpmtestutils.LuksVolumeName(tc.device)
 				}
+				activated, err := tpm.IsActivatedVolume(s, volumeName)
+				is.NoErr(err)       // the mount points state is readable
+				is.True(!activated) // the volume is not recorded as activated by the tool
+				return
 			}
 
-			content := fmt.Sprintf("ignored-line\n%s %s ext4 rw 0 0\n", mapper, mount)
-			if tc.readErr {
-				// Scanner default max token: 64K. This will return a read error
-				content = strings.Repeat("a", 70*1024) + "\n"
+			if tc.wantMapper == "<device>" {
+				tc.wantMapper = tpmtestutils.LuksVolumeName(tc.device)
 			}
+			want := filepath.Join(root, "dev", "mapper", tc.wantMapper)
+			is.Equal(got, want) // Activate returns the path of the decrypted device
 
-			tpmtestutils.SetupProcMount(is, root, content)
+			_, err = os.Stat(want)
+			is.NoErr(err) // the decrypted device is mapped
 
-			if tc.fileErr {
-				err := os.Remove(filepath.Join(root, "proc", "mounts"))
-				is.NoErr(err) // Setup: /proc/mounts should be deleted for a file error
+			activated, err := tpm.IsActivatedVolume(s, tc.wantMapper)
+			is.NoErr(err)      // the mount points state is readable
+			is.True(activated) // the volume is recorded as activated by the tool, to be mounted later
+		})
+	}
+}
+
+func TestDeactivateVolume(t *testing.T) {
+	tests := map[string]struct {
+		volume string
+
+		deviceMapped bool
+		mounted      bool
+
+		wantMapper string
+
+		wantErr   bool
+		wantErrIs error
+	}{
+		"Success with mapper name":       {volume: "test-volume", wantMapper: "test-volume"},
+		"Success with mapper path":       {volume: "/dev/mapper/test-volume", wantMapper: "test-volume"},
+		"Success with encrypted device":  {volume: "<device>", deviceMapped: true, wantMapper: "mapper-name"},
+		"Success with mapper under root": {volume: "<root>/dev/mapper/test-volume", wantMapper: "test-volume"},
+
+		"Error on empty volume":                     {wantErr: true},
+		"Error when mapper does not exist":          {volume: "other-volume", wantErr: true, wantErrIs: tpm.ErrNotActive},
+		"Error when encrypted device is not mapped": {volume: "<device>", wantErr: true, wantErrIs: tpm.ErrNotActive},
+		"Error when volume is mounted":              {volume: "test-volume", mounted: true, wantErr: true, wantErrIs: tpm.ErrAlreadyMounted},
+		"Error when systemd cryptsetup fails":       {volume: "exit-with-failure", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := testutils.ContextLoggerWithDebug(t)
+
+			root := t.TempDir()
+
+			// cryptsetup mock binary
+			tpmtestutils.SetupMockBinary(is, root)
+			t.Setenv("SNAP", root)
+
+			mapperDir := filepath.Join(root, "dev", "mapper")
+			err := os.MkdirAll(mapperDir, 0750)
+			is.NoErr(err) // Setup: mapper directory should exist
+			for _, m := range []string{"test-volume", "mapper-name", "exit-with-failure"} {
+				err = os.WriteFile(filepath.Join(mapperDir, m), nil, 0600)
+				is.NoErr(err) // Setup: decrypted device should be mapped
+			}
+
+			device := filepath.Join(root, "test-device")
+			tpmtestutils.SetupSysClassBlock(is, root, device, tc.deviceMapped)
+
+			content := ""
+			if tc.mounted {
+				content = fmt.Sprintf("%s %s ext4 rw 0 0\n", filepath.Join(mapperDir, "test-volume"), filepath.Join(root, "mount-dir"))
 			}
+			tpmtestutils.SetupMountInfo(is, root, content)
+
+			tc.volume = strings.ReplaceAll(tc.volume, "<device>", device)
+			tc.volume = strings.ReplaceAll(tc.volume, "<root>", root)
 
 			s := tpm.New(tpmtestutils.WithRoot(root))
+			for _, m := range []string{"test-volume", "mapper-name"} {
+				err := tpm.RecordActivatedVolume(s, m)
+				is.NoErr(err) // Setup: volume should be recorded as activated by the tool
+			}
 
-			m, err := tpm.SearchInProcMounts(s, tc.path, tc.fieldPath, tc.fieldResult)
+			got, err := s.Deactivate(ctx, tc.volume)
+			if tc.wantErrIs != nil {
+				is.True(errors.Is(err, tc.wantErrIs)) // Deactivate returns the expected error
+			}
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
 
-			want := ""
-			if !tc.notFound {
-				switch tc.fieldResult {
-				case 0:
-					want = mapper
-				case 1:
-					want = mount
-				}
-			}
+			is.Equal(got, filepath.Join(mapperDir, tc.wantMapper)) // Deactivate returns the path of the removed mapper
 
-			is.Equal(m, want) // the mount path is the expected one
+			activated, err := tpm.IsActivatedVolume(s, tc.wantMapper)
+			is.NoErr(err)       // the mount points state is readable
+			is.True(!activated) // the volume is forgotten once deactivated
 		})
 	}
 }
+
 func TestMain(m *testing.M) {
 	if filepath.Base(os.Args[0]) == "systemd-cryptsetup" {
 		tpmtestutils.SystemdCryptsetupMock()
@@ -284,6 +597,11 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// testCredential is the credential returned by authRequestor.
+//
+//nolint:gosec // Test recovery key.
+const testCredential = "22003-18216-51619-31723-49692-17125-14174-57839"
+
 type authRequestor struct {
 	requested bool
 
@@ -295,7 +613,7 @@ func (r *authRequestor) RequestUserCredential(ctx context.Context, name, path st
 		return "", 0, errors.New("test error")
 	}
 	r.requested = true
-	return "22003-18216-51619-31723-49692-17125-14174-57839", secboot.UserAuthTypeRecoveryKey, nil
+	return testCredential, authTypes, nil
 }
 
 func (r *authRequestor) NotifyUserAuthResult(ctx context.Context, result secboot.UserAuthResult, authTypes, exhaustedAuthTypes secboot.UserAuthType) error {
@@ -0,0 +1,201 @@
+package tpm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// mountInfo is a mount of the mount namespace of the tool, as described by a line of /proc/self/mountinfo.
+type mountInfo struct {
+	ID       int
+	ParentID int
+	// Major and Minor are the device number of the mounted filesystem. It is the same for all its mounts.
+	Major uint32
+	Minor uint32
+	// Root is the directory of the filesystem which is mounted. It is "/" unless a subdirectory was bind mounted.
+	Root       string
+	MountPoint string
+	Options    string
+	// OptionalFields are the propagation of the mount between mount namespaces, like shared:1 or master:2.
+	OptionalFields []string
+	FSType         string
+	Source         string
+	SuperOptions   string
+}
+
+// isBindMount returns true if only a subdirectory of the filesystem is mounted.
+// Mounts of a whole filesystem are only told apart from bind mounts of its root by being mounted first.
+func (m mountInfo) isBindMount() bool {
+	return m.Root != "/"
+}
+
+// readMountInfo returns the mounts of the mount namespace of the tool, in the order they were mounted.
+// Snaps run in their own mount namespace, which is the one their mounts are made in: the mounts of the
+// host are read from the mountinfo of the tool itself rather than from the one of init.
+func (s SnapTPM) readMountInfo() ([]mountInfo, error) {
+	file, err := os.Open(filepath.Join(s.root, "proc", "self", "mountinfo"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open /proc/self/mountinfo: %w", err)
+	}
+	defer file.Close()
+
+	var mounts []mountInfo
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		m, err := parseMountInfoLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("invalid /proc/self/mountinfo line %d: %w", n, err)
+		}
+		mounts = append(mounts, m)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading /proc/self/mountinfo: %w", err)
+	}
+
+	return mounts, nil
+}
+
+// parseMountInfoLine parses a line of mountinfo, like:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// Optional fields are ended by a single hyphen, and paths have their whitespaces and backslashes escaped in octal.
+func parseMountInfoLine(line string) (m mountInfo, err error) {
+	fields := strings.Fields(line)
+
+	sep := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			sep = i
+			break
+		}
+	}
+	if sep == -1 || len(fields) < sep+3 {
+		return m, errors.New("missing fields")
+	}
+
+	if m.ID, err = strconv.Atoi(fields[0]); err != nil {
+		return m, fmt.Errorf("invalid mount ID %q", fields[0])
+	}
+	if m.ParentID, err = strconv.Atoi(fields[1]); err != nil {
+		return m, fmt.Errorf("invalid parent ID %q", fields[1])
+	}
+
+	major, minor, ok := strings.Cut(fields[2], ":")
+	maj, errMaj := strconv.ParseUint(major, 10, 32)
+	mnr, errMnr := strconv.ParseUint(minor, 10, 32)
+	if !ok || errMaj != nil || errMnr != nil {
+		return m, fmt.Errorf("invalid device number %q", fields[2])
+	}
+	m.Major, m.Minor = uint32(maj), uint32(mnr)
+
+	m.Root = unescapeMountInfo(fields[3])
+	m.MountPoint = unescapeMountInfo(fields[4])
+	m.Options = fields[5]
+	m.OptionalFields = fields[6:sep]
+	m.FSType = fields[sep+1]
+	m.Source = unescapeMountInfo(fields[sep+2])
+	if len(fields) > sep+3 {
+		m.SuperOptions = fields[sep+3]
+	}
+
+	return m, nil
+}
+
+// unescapeMountInfo replaces the octal escapes of mountinfo, like \040 for spaces, by the characters they stand for.
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1:i+4]) {
+			c, _ := strconv.ParseUint(s[i+1:i+4], 8, 8)
+			b.WriteByte(byte(c))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// isOctal returns true if s is a 3 digits octal number of a byte.
+func isOctal(s string) bool {
+	_, err := strconv.ParseUint(s, 8, 8)
+	return len(s) == 3 && err == nil
+}
+
+// mountAt returns the mount at mountPoint. If several mounts are stacked on it, the last one, which is visible, is returned.
+func (s SnapTPM) mountAt(mountPoint string) (mountInfo, bool, error) {
+	mounts, err := s.readMountInfo()
+	if err != nil {
+		return mountInfo{}, false, err
+	}
+
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if mounts[i].MountPoint == mountPoint {
+			return mounts[i], true, nil
+		}
+	}
+
+	return mountInfo{}, false, nil
+}
+
+// mountsOf returns all the mounts, including bind mounts, of the filesystem on device.
+// Block devices are matched by device number, whatever the path they were mounted from, and other files by path.
+func (s SnapTPM) mountsOf(device string) ([]mountInfo, error) {
+	mounts, err := s.readMountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	match := func(m mountInfo) bool { return m.Source == device }
+	if info, err := os.Stat(device); err == nil && info.Mode()&os.ModeDevice != 0 {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			major, minor := unix.Major(st.Rdev), unix.Minor(st.Rdev)
+			match = func(m mountInfo) bool { return m.Major == major && m.Minor == minor }
+		}
+	}
+
+	var found []mountInfo
+	for _, m := range mounts {
+		if match(m) {
+			found = append(found, m)
+		}
+	}
+
+	return found, nil
+}
+
+// otherMountsOf returns the mounts of the filesystem mounted by m, except m itself.
+func (s SnapTPM) otherMountsOf(m mountInfo) ([]mountInfo, error) {
+	mounts, err := s.readMountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var others []mountInfo
+	for _, o := range mounts {
+		if o.ID != m.ID && o.Major == m.Major && o.Minor == m.Minor {
+			others = append(others, o)
+		}
+	}
+
+	return others, nil
+}
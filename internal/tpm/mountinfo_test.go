@@ -0,0 +1,57 @@
+package tpm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/testutils"
+	"github.com/canonical/snap-tpmctl/internal/testutils/golden"
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/matryer/is"
+)
+
+func TestReadMountInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		missingFile bool
+
+		wantErr bool
+	}{
+		"Success_parsing_mounts":                   {},
+		"Success_unescaping_paths":                 {},
+		"Success_with_bind_mounts":                 {},
+		"Success_with_mount_namespace_propagation": {},
+		"Success_with_empty_file":                  {},
+
+		"Error_on_missing_separator":     {wantErr: true},
+		"Error_on_missing_source":        {wantErr: true},
+		"Error_on_invalid_device_number": {wantErr: true},
+		"Error_on_invalid_mount_ID":      {wantErr: true},
+		"Error_on_invalid_parent_ID":     {wantErr: true},
+		"Error_when_file_is_missing":     {missingFile: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			// Each test has its own root with the mountinfo file to parse.
+			root := filepath.Join("testdata", t.Name())
+			if tc.missingFile {
+				root = t.TempDir()
+			}
+
+			s := tpm.New(tpmtestutils.WithRoot(root))
+
+			got, err := tpm.ReadMountInfo(s)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			golden.CheckOrUpdate(t, got) // TestReadMountInfo returns the expected mounts
+		})
+	}
+}
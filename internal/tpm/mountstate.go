@@ -0,0 +1,191 @@
+package tpm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+)
+
+// mountStateFile is the file, in the data directory of the snap, listing the mount points and the volumes created
+// by the tool.
+const mountStateFile = "mount-points.json"
+
+// mountState lists the mount points created by the tool, which are the only ones it removes when unmounting,
+// and the volumes it activated, which are the only active ones it mounts.
+type mountState struct {
+	MountPoints      []string `json:"mount-points"`
+	ActivatedVolumes []string `json:"activated-volumes,omitempty"`
+}
+
+// mountStatePath returns the path of the file listing the mount points created by the tool.
+func (s SnapTPM) mountStatePath() string {
+	// Confined snaps can only write to their own data directories.
+	dataDir := os.Getenv("SNAP_DATA")
+	if dataDir == "" {
+		dataDir = "/var/lib/snap-tpmctl"
+	}
+
+	return filepath.Join(s.root, dataDir, mountStateFile)
+}
+
+// loadMountState reads the mount points created by the tool. None were created if the state does not exist.
+func (s SnapTPM) loadMountState() (mountState, error) {
+	var state mountState
+
+	data, err := os.ReadFile(s.mountStatePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("unable to read mount points state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("unable to parse mount points state: %w", err)
+	}
+
+	return state, nil
+}
+
+// saveMountState atomically replaces the mount points created by the tool. The state must be locked.
+func (s SnapTPM) saveMountState(state mountState) error {
+	path := s.mountStatePath()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("unable to encode mount points state: %w", err)
+	}
+
+	// Write to a temporary file first, so that the state is never left partially written.
+	f, err := os.CreateTemp(filepath.Dir(path), mountStateFile+".*")
+	if err != nil {
+		return fmt.Errorf("unable to write mount points state: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if e := f.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write mount points state: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("unable to write mount points state: %w", err)
+	}
+
+	return nil
+}
+
+// lockMountState takes an exclusive lock on the mount points state, until the returned function is called.
+// The lock is held on a separate file, as saving the state replaces its file.
+func (s SnapTPM) lockMountState() (unlock func(), err error) {
+	path := s.mountStatePath() + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create mount points state directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock mount points state: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to lock mount points state: %w", err)
+	}
+
+	// Closing the file releases the lock.
+	return func() { _ = f.Close() }, nil
+}
+
+// updateMountState applies update to the mount points created by the tool, and saves them if update changed them.
+// The state is locked from reading to saving it, so that concurrent runs of the tool don't lose each other's changes.
+func (s SnapTPM) updateMountState(update func(*mountState) (changed bool)) error {
+	unlock, err := s.lockMountState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := s.loadMountState()
+	if err != nil {
+		return err
+	}
+
+	if !update(&state) {
+		return nil
+	}
+
+	return s.saveMountState(state)
+}
+
+// recordCreatedMountPoint remembers that the tool created the mount point target.
+func (s SnapTPM) recordCreatedMountPoint(target string) error {
+	return s.updateMountState(func(state *mountState) bool {
+		return addToList(&state.MountPoints, target)
+	})
+}
+
+// forgetCreatedMountPoint removes target from the mount points created by the tool.
+func (s SnapTPM) forgetCreatedMountPoint(target string) error {
+	return s.updateMountState(func(state *mountState) bool {
+		return removeFromList(&state.MountPoints, target)
+	})
+}
+
+// isCreatedMountPoint returns true if the tool created the mount point target.
+func (s SnapTPM) isCreatedMountPoint(target string) (bool, error) {
+	state, err := s.loadMountState()
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(state.MountPoints, target), nil
+}
+
+// recordActivatedVolume remembers that the tool activated the volume mapped as volumeName.
+func (s SnapTPM) recordActivatedVolume(volumeName string) error {
+	return s.updateMountState(func(state *mountState) bool {
+		return addToList(&state.ActivatedVolumes, volumeName)
+	})
+}
+
+// forgetActivatedVolume removes volumeName from the volumes activated by the tool.
+func (s SnapTPM) forgetActivatedVolume(volumeName string) error {
+	return s.updateMountState(func(state *mountState) bool {
+		return removeFromList(&state.ActivatedVolumes, volumeName)
+	})
+}
+
+// isActivatedVolume returns true if the tool activated the volume mapped as volumeName.
+func (s SnapTPM) isActivatedVolume(volumeName string) (bool, error) {
+	state, err := s.loadMountState()
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(state.ActivatedVolumes, volumeName), nil
+}
+
+// addToList appends v to list unless it is already in it. It returns true if list changed.
+func addToList(list *[]string, v string) bool {
+	if slices.Contains(*list, v) {
+		return false
+	}
+	*list = append(*list, v)
+	return true
+}
+
+// removeFromList removes v from list. It returns true if list changed.
+func removeFromList(list *[]string, v string) bool {
+	if !slices.Contains(*list, v) {
+		return false
+	}
+	*list = slices.DeleteFunc(*list, func(e string) bool { return e == v })
+	return true
+}
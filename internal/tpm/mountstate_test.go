@@ -0,0 +1,56 @@
+package tpm_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/canonical/snap-tpmctl/internal/tpm"
+	tpmtestutils "github.com/canonical/snap-tpmctl/internal/tpm/testutils"
+	"github.com/matryer/is"
+)
+
+func TestConcurrentMountStateUpdates(t *testing.T) {
+	t.Parallel()
+	is := is.New(t)
+
+	const n = 200
+
+	root := t.TempDir()
+	s := tpm.New(tpmtestutils.WithRoot(root))
+
+	targets := make([]string, n)
+	for i := range targets {
+		targets[i] = filepath.Join(root, fmt.Sprintf("mount-dir-%d", i))
+	}
+
+	// Every other mount point is forgotten while the others are recorded.
+	for i := 0; i < n; i += 2 {
+		is.NoErr(tpm.RecordCreatedMountPoint(s, targets[i])) // Setup: could not record mount point
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i, target := range targets {
+		wg.Go(func() {
+			if i%2 == 0 {
+				errs <- tpm.ForgetCreatedMountPoint(s, target)
+				return
+			}
+			errs <- tpm.RecordCreatedMountPoint(s, target)
+		})
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		is.NoErr(err) // Mount points state is updated
+	}
+
+	for i, target := range targets {
+		created, err := tpm.IsCreatedMountPoint(s, target)
+		is.NoErr(err)               // Mount points state is readable
+		is.Equal(created, i%2 == 1) // No concurrent update of the mount points state is lost
+	}
+}
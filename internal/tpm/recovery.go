@@ -4,47 +4,67 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/canonical/snap-tpmctl/internal/snapd"
 	"github.com/snapcore/secboot"
 )
 
 // CreateKey creates a new recovery key with the given name. Input should be validated using ValidateRecoveryKeyNameUnique first.
-func (s SnapTPM) CreateKey(ctx context.Context, recoveryKeyName string) (recoveryKey string, err error) {
+// The key is added to the containers with the given roles, or to both system-data and system-save if none is given.
+func (s SnapTPM) CreateKey(ctx context.Context, recoveryKeyName string, containerRoles []string) (recoveryKey string, err error) {
+	if len(containerRoles) > 0 {
+		if _, err := s.volumesForContainerRoles(ctx, containerRoles); err != nil {
+			return "", err
+		}
+	}
+
 	key, err := s.snapdClient.GenerateRecoveryKey(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate recovery key: %v", err)
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
 	}
 
-	keySlots := []snapd.Keyslot{{Name: recoveryKeyName}}
+	keySlots := recoveryKeyslots(recoveryKeyName, containerRoles)
 
 	if err := s.snapdClient.AddRecoveryKey(ctx, key.KeyID, keySlots); err != nil {
-		return "", fmt.Errorf("failed to add recovery key: %v", err)
+		return "", fmt.Errorf("failed to add recovery key: %w", err)
 	}
 
 	return key.RecoveryKey, nil
 }
 
 // RegenerateKey replaces an existing recovery key with a new one with the given name. Input should be validated using ValidateRecoveryKeyName first.
-func (s SnapTPM) RegenerateKey(ctx context.Context, recoveryKeyName string) (recoveryKey string, err error) {
+// The key is replaced in the containers with the given roles, or in both system-data and system-save if none is given.
+func (s SnapTPM) RegenerateKey(ctx context.Context, recoveryKeyName string, containerRoles []string) (recoveryKey string, err error) {
+	if len(containerRoles) > 0 {
+		if _, err := s.volumesForContainerRoles(ctx, containerRoles); err != nil {
+			return "", err
+		}
+	}
+
 	key, err := s.snapdClient.GenerateRecoveryKey(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate recovery key: %v", err)
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
 	}
 
-	keySlots := []snapd.Keyslot{{Name: recoveryKeyName}}
+	keySlots := recoveryKeyslots(recoveryKeyName, containerRoles)
 
 	if err := s.snapdClient.ReplaceRecoveryKey(ctx, key.KeyID, keySlots); err != nil {
-		return "", fmt.Errorf("failed to replace recovery key: %v", err)
+		return "", fmt.Errorf("failed to replace recovery key: %w", err)
 	}
 
 	return key.RecoveryKey, nil
 }
 
 // CheckKey verifies if a recovery key is valid by checking it against the system.
-func (s SnapTPM) CheckKey(ctx context.Context, recoveryKey string) (bool, error) {
-	ok, err := s.snapdClient.CheckRecoveryKey(ctx, recoveryKey, nil)
+// Only the containers with the given roles are checked, or all of them if none is given.
+func (s SnapTPM) CheckKey(ctx context.Context, recoveryKey string, containerRoles []string) (bool, error) {
+	if len(containerRoles) > 0 {
+		if _, err := s.volumesForContainerRoles(ctx, containerRoles); err != nil {
+			return false, err
+		}
+	}
+
+	ok, err := s.snapdClient.CheckRecoveryKey(ctx, recoveryKey, containerRoles)
 	if err != nil {
-		return false, fmt.Errorf("failed to check recovery key: %v", err)
+		return false, fmt.Errorf("failed to check recovery key: %w", err)
 	}
 
 	return ok, nil
@@ -54,7 +74,7 @@ func (s SnapTPM) CheckKey(ctx context.Context, recoveryKey string) (bool, error)
 func GetLuksKey(ctx context.Context, recoveryKey string) (secboot.DiskUnlockKey, error) {
 	binKey, err := secboot.ParseRecoveryKey(recoveryKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get LUKS key: %v", err)
+		return nil, fmt.Errorf("failed to get LUKS key: %w: %w", ErrInvalidRecoveryKey, err)
 	}
 
 	return binKey[:], nil
@@ -1,6 +1,7 @@
 package tpm_test
 
 import (
+	"errors"
 	"testing"
 
 	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
@@ -18,13 +19,22 @@ func TestCreateKey(t *testing.T) {
 	tests := map[string]struct {
 		recoveryKeyName string
 		recoveryKey     string
+		containerRoles  []string
 
-		wantErr bool
+		wantInBody string
+		wantErr    bool
 	}{
 		"Success_creating_recovery_key": {recoveryKeyName: "test", recoveryKey: "11272-47509-28031-54818-41671-38673-11053-06376"},
-
-		"Error_generating_recovery_key": {wantErr: true},
-		"Error_adding_recovery_key":     {wantErr: true},
+		"Success_creating_recovery_key_on_container_role": {
+			recoveryKeyName: "test",
+			recoveryKey:     "11272-47509-28031-54818-41671-38673-11053-06376",
+			containerRoles:  []string{"system-save"},
+			wantInBody:      `"keyslots":[{"container-role":"system-save","name":"test"}]`,
+		},
+
+		"Error_generating_recovery_key":   {wantErr: true},
+		"Error_adding_recovery_key":       {wantErr: true},
+		"Error_on_unknown_container_role": {containerRoles: []string{"system-boot"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -36,13 +46,16 @@ func TestCreateKey(t *testing.T) {
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 
-			got, err := s.CreateKey(ctx, tc.recoveryKeyName)
+			got, err := s.CreateKey(ctx, tc.recoveryKeyName, tc.containerRoles)
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
 			is.Equal(got, tc.recoveryKey)
 
 			tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.recoveryKeyName)
+			if tc.wantInBody != "" {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.wantInBody)
+			}
 		})
 	}
 }
@@ -54,13 +67,22 @@ func TestRegenerateKey(t *testing.T) {
 	tests := map[string]struct {
 		recoveryKeyName string
 		recoveryKey     string
+		containerRoles  []string
 
-		wantErr bool
+		wantInBody string
+		wantErr    bool
 	}{
 		"Success_regenerating_recovery_key": {recoveryKeyName: "test", recoveryKey: "11272-47509-28031-54818-41671-38673-11053-06376"},
-
-		"Error_generating_recovery_key": {wantErr: true},
-		"Error_adding_recovery_key":     {wantErr: true},
+		"Success_regenerating_recovery_key_on_container_role": {
+			recoveryKeyName: "test",
+			recoveryKey:     "11272-47509-28031-54818-41671-38673-11053-06376",
+			containerRoles:  []string{"system-save"},
+			wantInBody:      `"keyslots":[{"container-role":"system-save","name":"test"}]`,
+		},
+
+		"Error_generating_recovery_key":   {wantErr: true},
+		"Error_adding_recovery_key":       {wantErr: true},
+		"Error_on_unknown_container_role": {containerRoles: []string{"system-boot"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -72,13 +94,16 @@ func TestRegenerateKey(t *testing.T) {
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 
-			got, err := s.RegenerateKey(ctx, tc.recoveryKeyName)
+			got, err := s.RegenerateKey(ctx, tc.recoveryKeyName, tc.containerRoles)
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
 			is.Equal(got, tc.recoveryKey)
 
 			tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.recoveryKeyName)
+			if tc.wantInBody != "" {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.wantInBody)
+			}
 		})
 	}
 }
@@ -87,13 +112,21 @@ func TestCheckKey(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		recoveryKey string
+		recoveryKey    string
+		containerRoles []string
 
-		wantErr bool
+		wantInBody string
+		wantErr    bool
 	}{
 		"Success_checking_recovery_key": {recoveryKey: "11272-47509-28031-54818-41671-38673-11053-06376"},
-
-		"Error_checking_recovery_key": {wantErr: true},
+		"Success_checking_recovery_key_on_container_role": {
+			recoveryKey:    "11272-47509-28031-54818-41671-38673-11053-06376",
+			containerRoles: []string{"system-data", "system-save"},
+			wantInBody:     `"container-roles":["system-data","system-save"]`,
+		},
+
+		"Error_checking_recovery_key":     {wantErr: true},
+		"Error_on_unknown_container_role": {containerRoles: []string{"system-boot"}, wantErr: true},
 	}
 
 	for name, tc := range tests {
@@ -105,7 +138,7 @@ func TestCheckKey(t *testing.T) {
 			c := snapdtestutils.NewMockSnapdServer(t, ctx)
 			s := tpm.New(tpmtestutils.WithSnapdClient(c.Client))
 
-			got, err := s.CheckKey(ctx, tc.recoveryKey)
+			got, err := s.CheckKey(ctx, tc.recoveryKey, tc.containerRoles)
 			if testutils.CheckError(is, err, tc.wantErr) {
 				is.Equal(got, false)
 				return
@@ -113,6 +146,9 @@ func TestCheckKey(t *testing.T) {
 			is.Equal(got, true)
 
 			tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.recoveryKey)
+			if tc.wantInBody != "" {
+				tpmtestutils.OneRequestBodyContains(is, c.Requests, tc.wantInBody)
+			}
 		})
 	}
 }
@@ -137,6 +173,7 @@ func TestGetLuksKey(t *testing.T) {
 			ctx := testutils.ContextLoggerWithDebug(t)
 
 			got, err := tpm.GetLuksKey(ctx, tc.recoveryKey)
+			is.Equal(errors.Is(err, tpm.ErrInvalidRecoveryKey), tc.wantErr) // GetLuksKey reports malformed recovery keys
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
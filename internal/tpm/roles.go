@@ -0,0 +1,101 @@
+package tpm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/canonical/snap-tpmctl/internal/snapd"
+)
+
+// ContainerRoles returns the sorted roles of the encrypted containers of the system.
+func (s SnapTPM) ContainerRoles(ctx context.Context) ([]string, error) {
+	volumes, err := s.ListVolumeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptedContainerRoles(volumes), nil
+}
+
+// encryptedContainerRoles returns the sorted roles of the encrypted containers in volumes.
+func encryptedContainerRoles(volumes snapd.SystemVolumesResult) []string {
+	var roles []string
+	for role, structure := range volumes.ByContainerRole {
+		if structure.Encrypted {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+
+	return roles
+}
+
+// volumesForContainerRoles returns the system volumes after checking that all container roles exist on the system.
+func (s SnapTPM) volumesForContainerRoles(ctx context.Context, containerRoles []string) (snapd.SystemVolumesResult, error) {
+	volumes, err := s.ListVolumeInfo(ctx)
+	if err != nil {
+		return volumes, err
+	}
+
+	existing := encryptedContainerRoles(volumes)
+	for _, role := range containerRoles {
+		if !slices.Contains(existing, role) {
+			return volumes, fmt.Errorf("unknown container role %q, must be one of %v", role, existing)
+		}
+	}
+
+	return volumes, nil
+}
+
+// recoveryKeyslots returns the recovery keyslots with the given name on the container roles.
+// Without any container role, snapd targets both system-data and system-save.
+func recoveryKeyslots(name string, containerRoles []string) []snapd.Keyslot {
+	if len(containerRoles) == 0 {
+		return []snapd.Keyslot{{Name: name}}
+	}
+
+	keySlots := make([]snapd.Keyslot, 0, len(containerRoles))
+	for _, role := range containerRoles {
+		keySlots = append(keySlots, snapd.Keyslot{ContainerRole: role, Name: name})
+	}
+
+	return keySlots
+}
+
+// platformKeyslots returns the platform keyslots protected with authMode on the container roles.
+// Without any container role, it returns nil so that snapd targets its default keyslots.
+func (s SnapTPM) platformKeyslots(ctx context.Context, authMode snapd.AuthMode, containerRoles []string) ([]snapd.Keyslot, error) {
+	if len(containerRoles) == 0 {
+		return nil, nil
+	}
+
+	volumes, err := s.volumesForContainerRoles(ctx, containerRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySlots []snapd.Keyslot
+	for _, role := range containerRoles {
+		for name, slot := range volumes.ByContainerRole[role].Keyslots {
+			if slot.AuthMode != authMode {
+				continue
+			}
+			keySlots = append(keySlots, snapd.Keyslot{ContainerRole: role, Name: name})
+		}
+	}
+
+	if len(keySlots) == 0 {
+		return nil, fmt.Errorf("no %s keyslot found for container roles %v", authMode, containerRoles)
+	}
+
+	slices.SortFunc(keySlots, func(a, b snapd.Keyslot) int {
+		if c := strings.Compare(a.ContainerRole, b.ContainerRole); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return keySlots, nil
+}
@@ -29,3 +29,11 @@ func withSyscall(s syscaller) Option {
 		o.syscall = s
 	}
 }
+
+// withActivator allows you to specify a custom volume activator for testing purposes.
+func withActivator(a activator) Option {
+	testsdetection.MustBeTesting()
+	return func(o *options) {
+		o.activator = a
+	}
+}
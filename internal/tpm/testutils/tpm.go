@@ -2,6 +2,8 @@
 package tpmtestutils
 
 import (
+	"context"
+	"encoding/binary"
 	"errors"
 	"flag"
 	"fmt"
@@ -16,6 +18,7 @@ import (
 	"github.com/canonical/snap-tpmctl/internal/testutils/testsdetection"
 	"github.com/canonical/snap-tpmctl/internal/tpm"
 	"github.com/matryer/is"
+	"github.com/snapcore/secboot"
 )
 
 func init() {
@@ -34,7 +37,7 @@ func WithRoot(r string) tpm.Option
 
 // syscaller abstracts mount and unmount system calls used by SnapTPM.
 type syscaller interface {
-	Mount(path, target string) error
+	Mount(path, target, fsType string, flags uintptr) error
 	Unmount(target string) error
 }
 
@@ -43,6 +46,16 @@ type syscaller interface {
 //go:linkname WithSyscall github.com/canonical/snap-tpmctl/internal/tpm.withSyscall
 func WithSyscall(s syscaller) tpm.Option
 
+// activator abstracts the unlocking of volumes with the keyslots of their LUKS2 header.
+type activator interface {
+	Activate(ctx context.Context, volumeName, device string, authRequestor secboot.AuthRequestor, tries tpm.UnlockTries) error
+}
+
+// WithActivator is an option that configures the TPM to use the provided volume activator.
+//
+//go:linkname WithActivator github.com/canonical/snap-tpmctl/internal/tpm.withActivator
+func WithActivator(a activator) tpm.Option
+
 // LuksVolumeName converts a directory path into a valid LUKS volume name.
 //
 //go:linkname LuksVolumeName github.com/canonical/snap-tpmctl/internal/tpm.luksVolumeName
@@ -78,6 +91,26 @@ func SystemdCryptsetupMock() {
 		os.Exit(1)
 	}
 
+	// Map the device as is under the root of the test, as if it was decrypted, for its filesystem to be detected.
+	if args[0] == "attach" && os.Getenv("SNAP") != "" {
+		if _, err := os.Stat(args[2]); err != nil {
+			// Not every test has a filesystem to detect.
+			os.Exit(0)
+		}
+		if err := mapDevice(volumeName, args[2]); err != nil {
+			os.Exit(1)
+		}
+	}
+
+	// Unmap the device mapped when attaching it.
+	if args[0] == "detach" && os.Getenv("SNAP") != "" {
+		mapperPath := filepath.Join(os.Getenv("SNAP"), "dev", "mapper", volumeName)
+		//nolint:gosec // The mock only removes files under the root of the test.
+		if err := os.Remove(mapperPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			os.Exit(1)
+		}
+	}
+
 	os.Exit(0)
 }
 
@@ -94,6 +127,41 @@ func SetupMockBinary(is *is.I, root string) {
 	is.NoErr(err) // Setup: could not create symlink for mock cryptsetup binary
 }
 
+// SetupFilesystem writes the superblock of a filesystem of the given type to the device at path, which is
+// created if needed. ext2, ext3, ext4, xfs, vfat and ntfs are supported, and no superblock is written for
+// any other type.
+func SetupFilesystem(is *is.I, path, fsType string) {
+	is.Helper()
+
+	buf := make([]byte, 4096)
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		sb := buf[1024:]
+		binary.LittleEndian.PutUint16(sb[0x38:], 0xef53)
+		if fsType != "ext2" {
+			// has_journal
+			binary.LittleEndian.PutUint32(sb[0x5c:], 0x4)
+		}
+		if fsType == "ext4" {
+			// filetype, extents, 64bit and flex_bg
+			binary.LittleEndian.PutUint32(sb[0x60:], 0x2|0x40|0x80|0x200)
+		}
+	case "xfs":
+		copy(buf, "XFSB")
+	case "vfat":
+		copy(buf[0x52:], "FAT32   ")
+		copy(buf[510:], "\x55\xaa")
+	case "ntfs":
+		copy(buf[3:], "NTFS    ")
+		copy(buf[510:], "\x55\xaa")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0750)
+	is.NoErr(err) // Setup: could not create device directory
+	err = os.WriteFile(path, buf, 0600)
+	is.NoErr(err) // Setup: could not write filesystem superblock
+}
+
 // SetupSysClassBlock creates a mock /sys/class/block/<devname>/holders/ directory
 // with a fake holder entry, simulating a device already open by another tool.
 func SetupSysClassBlock(is *is.I, root, device string, deviceInUse bool) {
@@ -118,42 +186,255 @@ func SetupSysClassBlock(is *is.I, root, device string, deviceInUse bool) {
 	}
 }
 
-// SetupProcMount creates a mock /proc/mounts file with the provided content under root.
-func SetupProcMount(is *is.I, root, content string) {
+// SetupMountInfo creates a mock /proc/self/mountinfo file under root, with the mounts of content.
+// Mounts are given like in /proc/mounts, as "<source> <mount point> <fstype> ...", and are converted to the
+// mountinfo format, each source getting its own device number. Lines already in the mountinfo format, and
+// lines which are not mounts, are written as is.
+func SetupMountInfo(is *is.I, root, content string) {
 	is.Helper()
 
-	err := os.MkdirAll(filepath.Join(root, "proc"), 0750)
-	is.NoErr(err)
-	f, err := os.Create(filepath.Join(root, "proc", "mounts"))
-	is.NoErr(err)
-	defer f.Close()
+	var entries []string
+	var mounts []mountInfoEntry
+	for l := range strings.Lines(content) {
+		l = strings.TrimSuffix(l, "\n")
+
+		fields := strings.Fields(l)
+		if len(fields) < 3 || slices.Contains(fields, "-") {
+			entries = append(entries, l)
+			continue
+		}
+
+		m := newMountInfoEntry(mounts, fields[0], fields[1], fields[2])
+		mounts = append(mounts, m)
+		entries = append(entries, m.String())
+	}
+
+	err := os.MkdirAll(filepath.Join(root, "proc", "self"), 0750)
+	is.NoErr(err) // Setup: could not create mock /proc/self directory
+	err = os.WriteFile(filepath.Join(root, "proc", "self", "mountinfo"), []byte(joinLines(entries)), 0600)
+	is.NoErr(err) // Setup: could not write mock /proc/self/mountinfo file
+}
+
+// mountInfoEntry is a mount in a mock /proc/self/mountinfo file.
+type mountInfoEntry struct {
+	id    int
+	minor int
+	// mountPoint and source are escaped, like in mountinfo.
+	mountPoint string
+	fsType     string
+	source     string
+}
+
+// newMountInfoEntry returns the entry of a new mount after the ones of mounts, with the device number of the
+// other mounts of source if any. mountPoint and source are escaped.
+func newMountInfoEntry(mounts []mountInfoEntry, source, mountPoint, fsType string) mountInfoEntry {
+	m := mountInfoEntry{id: 100, minor: 0, mountPoint: mountPoint, fsType: fsType, source: source}
+
+	minor := -1
+	for _, o := range mounts {
+		m.id = max(m.id, o.id+1)
+		m.minor = max(m.minor, o.minor+1)
+		if o.source == source {
+			minor = o.minor
+		}
+	}
+	if minor != -1 {
+		m.minor = minor
+	}
+
+	return m
+}
+
+// parseMountInfoEntry parses a line of a mock /proc/self/mountinfo file, written by String.
+func parseMountInfoEntry(line string) (mountInfoEntry, bool) {
+	fields := strings.Fields(line)
+	sep := slices.Index(fields, "-")
+	if sep < 6 || len(fields) < sep+3 {
+		return mountInfoEntry{}, false
+	}
+
+	var m mountInfoEntry
+	if _, err := fmt.Sscanf(fields[0]+" "+fields[2], "%d 253:%d", &m.id, &m.minor); err != nil {
+		return mountInfoEntry{}, false
+	}
+	m.mountPoint = fields[4]
+	m.fsType = fields[sep+1]
+	m.source = fields[sep+2]
+
+	return m, true
+}
+
+// String returns the line of the mount in /proc/self/mountinfo.
+func (m mountInfoEntry) String() string {
+	return fmt.Sprintf("%d 1 253:%d / %s rw,relatime shared:%d - %s %s rw", m.id, m.minor, m.mountPoint, m.id, m.fsType, m.source)
+}
+
+// escapeMountInfo escapes the whitespaces and backslashes of p in octal, like in mountinfo.
+func escapeMountInfo(p string) string {
+	return strings.NewReplacer(" ", `\040`, "\t", `\011`, "\n", `\012`, `\`, `\134`).Replace(p)
+}
 
-	_, err = f.WriteString(content)
-	is.NoErr(err)
+// joinLines returns lines ended by new lines.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
 }
 
 // TestSyscall is a test implementation of mount and unmount system calls.
 type TestSyscall struct {
+	// Root is where the mountinfo file to update is, set with SetupMountInfo. It is not updated when empty.
+	Root string
+	// KeepMounted leaves the target mounted when unmounting, like a mount stacked below.
+	KeepMounted bool
+
 	Mounted   bool
 	Unmounted bool
 
+	// Source, FSType and Flags are the ones the volume was mounted with.
+	Source string
+	FSType string
+	Flags  uintptr
+
 	WantErr bool
 }
 
 // Mount records a mount call and optionally returns a test error.
-func (t *TestSyscall) Mount(path, target string) error {
+func (t *TestSyscall) Mount(path, target, fsType string, flags uintptr) error {
 	if t.WantErr {
 		return errors.New("test error")
 	}
 	t.Mounted = true
-	return nil
+	t.Source = path
+	t.FSType = fsType
+	t.Flags = flags
+
+	if t.Root == "" {
+		return nil
+	}
+	return t.updateMountInfo(func(lines []string, mounts []mountInfoEntry) []string {
+		m := newMountInfoEntry(mounts, escapeMountInfo(path), escapeMountInfo(target), fsType)
+		return append(lines, m.String())
+	})
 }
 
 // Unmount records an unmount call and optionally returns a test error.
+// Only the last mount on target, which is the visible one, is unmounted.
 func (t *TestSyscall) Unmount(target string) error {
 	if t.WantErr {
 		return errors.New("test error")
 	}
 	t.Unmounted = true
-	return nil
+
+	if t.Root == "" || t.KeepMounted {
+		return nil
+	}
+	return t.updateMountInfo(func(lines []string, _ []mountInfoEntry) []string {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if m, ok := parseMountInfoEntry(lines[i]); ok && m.mountPoint == escapeMountInfo(target) {
+				return slices.Delete(lines, i, i+1)
+			}
+		}
+		return lines
+	})
+}
+
+// updateMountInfo replaces the lines of the mountinfo file under Root by the ones returned by update,
+// which is given the current lines and the mounts they describe.
+func (t *TestSyscall) updateMountInfo(update func(lines []string, mounts []mountInfoEntry) []string) error {
+	path := filepath.Join(t.Root, "proc", "self", "mountinfo")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	var mounts []mountInfoEntry
+	for l := range strings.Lines(string(data)) {
+		l = strings.TrimSuffix(l, "\n")
+		lines = append(lines, l)
+		if m, ok := parseMountInfoEntry(l); ok {
+			mounts = append(mounts, m)
+		}
+	}
+
+	return os.WriteFile(path, []byte(joinLines(update(lines, mounts))), 0600)
+}
+
+// TestActivator is a test implementation of the unlocking of volumes with the keyslots of their LUKS2 header.
+// Like secboot, it asks for a credential until the right one is given or there are no tries left, and notifies
+// the result of each attempt.
+type TestActivator struct {
+	// Credential is the one unlocking the volume.
+	Credential string
+
+	// Tries are the ones the volume was unlocked with.
+	Tries tpm.UnlockTries
+	// Activated is true if the volume was unlocked.
+	Activated bool
+}
+
+// Activate unlocks the volume once the credential is given, and maps the device as is under $SNAP.
+func (t *TestActivator) Activate(ctx context.Context, volumeName, device string, authRequestor secboot.AuthRequestor, tries tpm.UnlockTries) error {
+	t.Tries = tries
+
+	left := map[secboot.UserAuthType]*uint{
+		secboot.UserAuthTypePassphrase:  &tries.Passphrase,
+		secboot.UserAuthTypePIN:         &tries.PIN,
+		secboot.UserAuthTypeRecoveryKey: &tries.RecoveryKey,
+	}
+	available := func() secboot.UserAuthType {
+		var authTypes secboot.UserAuthType
+		for authType, n := range left {
+			if *n > 0 {
+				authTypes |= authType
+			}
+		}
+		return authTypes
+	}
+
+	for authTypes := available(); authTypes != 0; authTypes = available() {
+		cred, _, err := authRequestor.RequestUserCredential(ctx, volumeName, device, authTypes)
+		if err != nil {
+			return fmt.Errorf("cannot request user credential: %w", err)
+		}
+
+		if cred == t.Credential {
+			t.Activated = true
+			if err := authRequestor.NotifyUserAuthResult(ctx, secboot.UserAuthResultSuccess, authTypes, 0); err != nil {
+				return err
+			}
+			return mapDevice(volumeName, device)
+		}
+
+		for _, n := range left {
+			if *n > 0 {
+				*n--
+			}
+		}
+		if err := authRequestor.NotifyUserAuthResult(ctx, secboot.UserAuthResultFailed, authTypes, authTypes&^available()); err != nil {
+			return err
+		}
+	}
+
+	return errors.New("cannot activate: no more tries remaining")
+}
+
+// mapDevice copies device as volumeName under the mapper directory of $SNAP, as if it was decrypted.
+func mapDevice(volumeName, device string) error {
+	data, err := os.ReadFile(device)
+	if err != nil {
+		return err
+	}
+
+	mapperPath := filepath.Join(os.Getenv("SNAP"), "dev", "mapper", volumeName)
+	//nolint:gosec // The mock only writes under the root of the test.
+	if err := os.MkdirAll(filepath.Dir(mapperPath), 0750); err != nil {
+		return err
+	}
+
+	//nolint:gosec // The mock only writes under the root of the test.
+	return os.WriteFile(mapperPath, data, 0600)
 }
@@ -3,13 +3,30 @@ package tpm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	_ "unsafe" // Needed for go:linkname.
 
 	"github.com/canonical/snap-tpmctl/internal/snapd"
 )
 
+var (
+	// ErrAlreadyMapped is returned when the device to activate is already mapped, by this tool or another one.
+	ErrAlreadyMapped = errors.New("resource is already mapped")
+	// ErrAlreadyMounted is returned when the volume to mount is already mounted.
+	ErrAlreadyMounted = errors.New("resource is already mounted")
+	// ErrNotMounted is returned when the path to unmount is not the mount point of a volume.
+	ErrNotMounted = errors.New("path not found in /proc/self/mountinfo")
+	// ErrNotActive is returned when the volume to deactivate is not mapped.
+	ErrNotActive = errors.New("volume is not active")
+	// ErrInvalidRecoveryKey is returned when a recovery key is malformed.
+	ErrInvalidRecoveryKey = errors.New("invalid recovery key")
+)
+
 // SnapTPM provides methods to interact with TPM/FDE features via snapd.
 type SnapTPM struct {
 	options
@@ -18,8 +35,9 @@ type SnapTPM struct {
 type options struct {
 	snapdClient *snapd.Client
 
-	root    string
-	syscall syscaller
+	root      string
+	syscall   syscaller
+	activator activator
 }
 
 // Option is a functional option for configuring the SnapTPM.
@@ -27,7 +45,7 @@ type Option func(*options)
 
 // syscaller abstracts mount and unmount system calls used by SnapTPM.
 type syscaller interface {
-	Mount(path, target string) error
+	Mount(path, target, fsType string, flags uintptr) error
 	Unmount(target string) error
 }
 
@@ -36,8 +54,9 @@ func New(args ...Option) SnapTPM {
 	o := options{
 		snapdClient: snapd.New(),
 
-		root:    "/",
-		syscall: defaultSyscall{},
+		root:      "/",
+		syscall:   defaultSyscall{},
+		activator: defaultActivator{},
 	}
 	for _, f := range args {
 		f(&o)
@@ -52,7 +71,7 @@ func New(args ...Option) SnapTPM {
 func (s SnapTPM) FdeStatus(ctx context.Context) (string, error) {
 	status, err := s.snapdClient.FdeStatus(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve the FDE status: %v", err)
+		return "", fmt.Errorf("failed to retrieve the FDE status: %w", err)
 	}
 
 	return status, nil
@@ -62,16 +81,26 @@ func (s SnapTPM) FdeStatus(ctx context.Context) (string, error) {
 func (s SnapTPM) ListVolumeInfo(ctx context.Context) (result snapd.SystemVolumesResult, err error) {
 	result, err = s.snapdClient.ListVolumeInfo(ctx)
 	if err != nil {
-		return result, fmt.Errorf("failed to retrieve the volume info: %v", err)
+		return result, fmt.Errorf("failed to retrieve the volume info: %w", err)
 	}
 
 	return result, nil
 }
 
+// MachineID returns the identifier of the local machine, as recorded by systemd.
+func (s SnapTPM) MachineID() (string, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, "etc", "machine-id"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read machine ID: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
 type defaultSyscall struct{}
 
-func (defaultSyscall) Mount(path, target string) error {
-	return syscall.Mount(path, target, "ext4", syscall.MS_RELATIME, "rw")
+func (defaultSyscall) Mount(path, target, fsType string, flags uintptr) error {
+	return syscall.Mount(path, target, fsType, flags, "rw")
 }
 func (defaultSyscall) Unmount(target string) error {
 	return syscall.Unmount(target, 0)
@@ -1,6 +1,8 @@
 package tpm_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	snapdtestutils "github.com/canonical/snap-tpmctl/internal/snapd/testutils"
@@ -71,3 +73,44 @@ func TestListVolumeInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content   string
+		noMachine bool
+
+		want    string
+		wantErr bool
+	}{
+		"Returns_machine_ID":                    {content: "0123456789abcdef0123456789abcdef\n", want: "0123456789abcdef0123456789abcdef"},
+		"Returns_machine_ID_without_whitespace": {content: " 0123456789abcdef0123456789abcdef ", want: "0123456789abcdef0123456789abcdef"},
+
+		"Error_when_machine_ID_is_missing": {noMachine: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			is := is.New(t)
+
+			root := t.TempDir()
+			if !tc.noMachine {
+				err := os.MkdirAll(filepath.Join(root, "etc"), 0o750)
+				is.NoErr(err) // Setup: could not create etc directory
+				err = os.WriteFile(filepath.Join(root, "etc", "machine-id"), []byte(tc.content), 0o600)
+				is.NoErr(err) // Setup: could not write machine-id
+			}
+
+			s := tpm.New(tpmtestutils.WithRoot(root))
+
+			got, err := s.MachineID()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(got, tc.want) // MachineID returns the expected machine ID
+		})
+	}
+}
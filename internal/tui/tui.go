@@ -2,10 +2,13 @@
 package tui
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"text/tabwriter"
@@ -13,6 +16,7 @@ import (
 
 	"github.com/snapcore/snapd/progress"
 	"golang.org/x/term"
+	"rsc.io/qr"
 )
 
 // these are the bits of the ANSI escapes (beyond \r) that we use
@@ -26,8 +30,15 @@ var (
 	cursorInvisible = "\033[?25l"
 	// make cursor visible.
 	cursorVisible = "\033[?25h"
+	// turn off all attributes.
+	exitAttributeMode = "\033[0m"
+	// white foreground on black background.
+	lightOnDark = "\033[37;40m"
 )
 
+// spinnerFrames are the characters cycled through to animate a spinner.
+var spinnerFrames = []string{"/", "-", "\\", "|"}
+
 // TerminalReader defines the input stream contract required by Tui.
 type TerminalReader interface {
 	io.Reader
@@ -38,11 +49,64 @@ type TerminalReader interface {
 type Tui struct {
 	r TerminalReader
 	w io.Writer
+
+	// in buffers the input of r. It is shared by all copies of the Tui, so that no input buffered by
+	// a previous read is lost.
+	in *bufio.Reader
+
+	// secrets is shared by all copies of the Tui, so that they all read from the same source.
+	secrets *secretSource
+}
+
+// secretSource provides secrets one per line when they are not typed in the terminal.
+type secretSource struct {
+	r *bufio.Reader
 }
 
 // New returns a Tui configured with the provided reader and writer streams.
 func New(r TerminalReader, w io.Writer) Tui {
-	return Tui{r, w}
+	var in *bufio.Reader
+	if r != nil {
+		in = bufio.NewReader(r)
+	}
+	return Tui{r: r, w: w, in: in, secrets: &secretSource{}}
+}
+
+// SetSecretSource makes the Tui read secrets from r, one per line, instead of prompting for them in the terminal.
+func (t Tui) SetSecretSource(r io.Reader) {
+	t.secrets.r = bufio.NewReader(r)
+}
+
+// Interactive returns true if secrets are typed in the terminal, in which case they can be confirmed.
+func (t Tui) Interactive() bool {
+	return t.secrets.r == nil
+}
+
+// IsTerminal returns true if the input reader is a terminal, in which case input can be typed with masked echo.
+func (t Tui) IsTerminal() bool {
+	if t.r == nil {
+		return false
+	}
+
+	ptr := t.r.Fd()
+	const maxInt = int(^uint(0) >> 1)
+	if ptr > uintptr(maxInt) {
+		return false
+	}
+
+	return term.IsTerminal(int(ptr))
+}
+
+// readSecretLine returns the next secret from the secret source.
+func (t Tui) readSecretLine() (string, error) {
+	line, err := t.secrets.r.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", errors.New("failed to read input: no more secrets provided")
+	} else if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
 }
 
 // Writer returns the output writer configured for this Tui instance.
@@ -73,11 +137,15 @@ func (t Tui) ShowCursor() {
 
 // ReadUserSecret prompts the user for sensitive input with asterisk echo on typing.
 func (t Tui) ReadUserSecret(prompt string) (string, error) {
+	if !t.Interactive() {
+		return t.readSecretLine()
+	}
+
 	fmt.Fprint(t.w, prompt)
 
-	input, err := t.readMaskedInput(0)
+	input, err := t.readMaskedInput(0, maxSecretLen)
 	if err != nil {
-		return "", fmt.Errorf("failed to read input: %v", err)
+		return "", fmt.Errorf("failed to read input: %w", err)
 	}
 	fmt.Fprintln(t.w)
 
@@ -86,17 +154,94 @@ func (t Tui) ReadUserSecret(prompt string) (string, error) {
 
 // ReadRecoveryKey prompts the user for entering a recovery key with automatic grouping hyphens.
 func (t Tui) ReadRecoveryKey() (string, error) {
+	if !t.Interactive() {
+		key, err := t.readSecretLine()
+		// Keep the value normalized, as when typed.
+		return strings.ReplaceAll(key, "-", ""), err
+	}
+
 	fmt.Fprint(t.w, "Enter recovery key: ")
 
-	input, err := t.readMaskedInput(5)
+	input, err := t.readMaskedInput(5, maxInputLen)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	fmt.Fprintln(t.w)
+
+	return string(input), nil
+}
+
+// ReadRecoveryKeyShare prompts the user for entering a share of a split recovery key with automatic grouping hyphens.
+func (t Tui) ReadRecoveryKeyShare(prompt string) (string, error) {
+	if !t.Interactive() {
+		share, err := t.readSecretLine()
+		// Keep the value normalized, as when typed.
+		return strings.ReplaceAll(share, "-", ""), err
+	}
+
+	fmt.Fprint(t.w, prompt)
+
+	input, err := t.readMaskedInput(4, maxInputLen)
 	if err != nil {
-		return "", fmt.Errorf("failed to read input: %v", err)
+		return "", fmt.Errorf("failed to read input: %w", err)
 	}
 	fmt.Fprintln(t.w)
 
 	return string(input), nil
 }
 
+// Confirm asks a yes/no question until a valid answer is given. An empty answer selects defaultYes,
+// which is also the answer when secrets are not typed in the terminal, as nobody is there to answer.
+func (t Tui) Confirm(question string, defaultYes bool) (bool, error) {
+	if !t.Interactive() {
+		return defaultYes, nil
+	}
+
+	choices := "[y/N]"
+	if defaultYes {
+		choices = "[Y/n]"
+	}
+
+	for {
+		fmt.Fprintf(t.w, "%s %s ", question, choices)
+
+		answer, err := t.readLine()
+		if err != nil {
+			return false, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return defaultYes, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// WaitForEnter blocks until the user presses Enter.
+func (t Tui) WaitForEnter() error {
+	if _, err := t.readLine(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil
+}
+
+// readLine reads a line typed in the terminal. The last line can be ended by the end of the input instead.
+func (t Tui) readLine() (string, error) {
+	if t.in == nil {
+		return "", errors.New("no input available")
+	}
+
+	line, err := t.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	return line, nil
+}
+
 // Spin provides a simple interface to start and stop a spinner in the terminal.
 func (t Tui) Spin(msg string) (stop func()) {
 	var spinner progress.ANSIMeter
@@ -131,6 +276,84 @@ func (t Tui) Spin(msg string) (stop func()) {
 	}
 }
 
+// Task is a step of a long running operation, as listed by SpinTasks.
+type Task struct {
+	Summary string
+	Status  string
+}
+
+// SpinTasks starts a spinner in the terminal followed by the list of tasks provided with update and their status.
+func (t Tui) SpinTasks(msg string) (update func([]Task), stop func()) {
+	var mu sync.Mutex
+	var tasks []Task
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		// Timer to trigger redrawing the spinner and the task list
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		// Hide cursor while spinning
+		t.HideCursor()
+		var drawn, frame int
+		for {
+			select {
+			case <-done:
+				t.redraw(drawn, nil)
+				fmt.Fprint(t.w, "\r", exitAttributeMode, cursorVisible, clrEOL)
+				return
+			case <-ticker.C:
+				mu.Lock()
+				lines := []string{fmt.Sprintf("%s %s", msg, spinnerFrames[frame%len(spinnerFrames)])}
+				for _, task := range tasks {
+					lines = append(lines, fmt.Sprintf("  %s… %s", task.Summary, task.Status))
+				}
+				mu.Unlock()
+
+				frame++
+				drawn = t.redraw(drawn, lines)
+			}
+		}
+	})
+
+	update = func(ts []Task) {
+		mu.Lock()
+		defer mu.Unlock()
+		tasks = slices.Clone(ts)
+	}
+
+	stop = func() {
+		if done == nil {
+			return
+		}
+
+		close(done)
+		wg.Wait()
+		done = nil
+	}
+
+	return update, stop
+}
+
+// redraw replaces the previously drawn block of lines with the new ones and returns the height of the new block.
+func (t Tui) redraw(drawn int, lines []string) int {
+	var b strings.Builder
+	b.WriteString(strings.Repeat(cursorUp, drawn))
+	for _, l := range lines {
+		b.WriteString("\r" + clrEOL + l + "\n")
+	}
+
+	// Erase what is left of a taller previous block.
+	if extra := drawn - len(lines); extra > 0 {
+		b.WriteString(strings.Repeat("\r"+clrEOL+"\n", extra))
+		b.WriteString(strings.Repeat(cursorUp, extra))
+	}
+
+	fmt.Fprint(t.w, b.String())
+	return len(lines)
+}
+
 // DisplayTable writes a formatted table to the given writer with optional headers.
 func (t Tui) DisplayTable(headers []string, rows [][]string, hideHeaders bool) error {
 	if len(rows) == 0 {
@@ -159,9 +382,83 @@ func (t Tui) DisplayTable(headers []string, rows [][]string, hideHeaders bool) e
 	return nil
 }
 
+// qrQuietZone is the number of light modules surrounding a QR code, as required for scanners to detect it.
+const qrQuietZone = 4
+
+// DisplayQRCode renders content as a QR code with half-block characters, two modules per character cell,
+// and returns the number of printed lines. Colors are forced so that it scans whatever the terminal theme is.
+func (t Tui) DisplayQRCode(content string) (lines int, err error) {
+	code, err := qr.Encode(content, qr.M)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	light := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= code.Size || y >= code.Size {
+			return true
+		}
+		return !code.Black(x, y)
+	}
+
+	var b strings.Builder
+	for y := -qrQuietZone; y < code.Size+qrQuietZone; y += 2 {
+		b.WriteString(lightOnDark)
+		for x := -qrQuietZone; x < code.Size+qrQuietZone; x++ {
+			switch top, bottom := light(x, y), light(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(exitAttributeMode + "\n")
+		lines++
+	}
+
+	fmt.Fprint(t.w, b.String())
+	return lines, nil
+}
+
+// strengthMeterWidth is the number of cells of the strength meter of a secret.
+const strengthMeterWidth = 20
+
+// DisplayStrength draws a meter of the strength of a new secret, from its entropy compared to
+// the minimum entropy it requires and to the optimal one.
+func (t Tui) DisplayStrength(entropy, minEntropy, optimalEntropy uint32) {
+	// The meter is full at the optimal entropy, or once the secret is accepted if there is none.
+	full := max(optimalEntropy, minEntropy)
+	filled := strengthMeterWidth
+	if entropy < full {
+		filled = int(entropy * strengthMeterWidth / full)
+	}
+
+	var label string
+	switch {
+	case entropy < minEntropy:
+		label = "too weak"
+	case entropy < (minEntropy+optimalEntropy)/2:
+		label = "fair"
+	case entropy < optimalEntropy:
+		label = "good"
+	default:
+		label = "strong"
+	}
+
+	fmt.Fprintf(t.w, "Strength: [%s%s] %s (%d bits of entropy, %d required)\n",
+		strings.Repeat("█", filled), strings.Repeat("░", strengthMeterWidth-filled), label, entropy, minEntropy)
+}
+
+// maxInputLen is the maximum length of a recovery key, or of one of its shares.
 const maxInputLen = 40
 
-func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
+// maxSecretLen is the maximum length of a PIN or passphrase, long enough for generated passphrases.
+const maxSecretLen = 128
+
+func (t Tui) readMaskedInput(groupEvery, maxLen int) ([]byte, error) {
 	ptr := t.r.Fd()
 	const maxInt = int(^uint(0) >> 1)
 	if ptr > uintptr(maxInt) {
@@ -188,7 +485,7 @@ func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
 		lastIsSep := len(masked) > 0 && masked[len(masked)-1] == '-'
 		atGroupBoundary := groupEvery > 0 && len(ret)%groupEvery == 0
 
-		n, err := t.r.Read(buf[:])
+		n, err := t.in.Read(buf[:])
 		if err != nil {
 			if errors.Is(err, io.EOF) && len(ret) > 0 {
 				return ret, nil
@@ -199,9 +496,9 @@ func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
 			continue
 		}
 
-		switch buf[0] {
+		switch c := buf[0]; {
 		// Case for backspace and delete (ASCII: 127)
-		case '\b', 127:
+		case c == '\b' || c == 127:
 			if len(masked) == 0 {
 				continue
 			}
@@ -220,10 +517,11 @@ func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
 				fmt.Fprint(t.w, "\b \b")
 			}
 
-		case '\n', '\r':
+		case c == '\n' || c == '\r':
 			return ret, nil
 
-		case '-':
+		// Dashes are only separators of grouped input, and part of the value otherwise.
+		case c == '-' && groupEvery > 0:
 			// Keep the internal value normalized while echoing typed separators.
 			if len(ret) > 0 && atGroupBoundary && !lastIsSep {
 				fmt.Fprint(t.w, "-")
@@ -231,12 +529,12 @@ func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
 			}
 			continue
 
-		// Case for Ctrl+C (ASCII: 3)
-		case 3:
-			return nil, nil
+		// Case for Ctrl+C (ASCII: 3), which does not raise SIGINT in raw mode.
+		case c == 3:
+			return nil, context.Canceled
 
 		default:
-			if len(ret) >= maxInputLen {
+			if len(ret) >= maxLen {
 				continue
 			}
 			// Print '-' before the first char of each next group.
@@ -244,7 +542,7 @@ func (t Tui) readMaskedInput(groupEvery int) ([]byte, error) {
 				fmt.Fprint(t.w, "-")
 				masked = append(masked, '-')
 			}
-			ret = append(ret, buf[0])
+			ret = append(ret, c)
 			fmt.Fprint(t.w, "*")
 			masked = append(masked, '*')
 		}
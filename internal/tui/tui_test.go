@@ -1,6 +1,8 @@
 package tui_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -66,6 +68,40 @@ func TestSpin(t *testing.T) {
 	})
 }
 
+func TestSpinTasks(t *testing.T) {
+	t.Parallel()
+
+	var buf syncBuffer
+	ui := tui.New(nil, &buf)
+
+	synctest.Test(t, func(t *testing.T) {
+		is := is.New(t)
+
+		update, stop := ui.SpinTasks("Some message...")
+		defer stop()
+
+		for _, tasks := range [][]tui.Task{
+			nil,
+			{{Summary: "First task", Status: "running"}, {Summary: "Second task", Status: "pending"}},
+			{{Summary: "First task", Status: "done"}, {Summary: "Second task", Status: "running (1/2)"}},
+			{{Summary: "First task", Status: "done"}},
+		} {
+			update(tasks)
+			time.Sleep(100 * time.Millisecond)
+			synctest.Wait()
+
+			for _, task := range tasks {
+				is.True(strings.Contains(buf.String(), task.Summary+"… "+task.Status)) // task status is displayed
+			}
+		}
+
+		stop()
+		synctest.Wait()
+
+		golden.CheckOrUpdate(t, buf.String()) // TestSpinTasks returns the expected spinner and task list output
+	})
+}
+
 func TestReadSecret(t *testing.T) {
 	t.Parallel()
 
@@ -73,14 +109,18 @@ func TestReadSecret(t *testing.T) {
 		input        string
 		ttyReadError bool
 
-		wantErr bool
+		wantErr   bool
+		wantErrIs error
 	}{
-		"Success":                    {},
-		"Success_backspace":          {input: "test\bx\n"},
-		"Success_ctrl_c":             {input: "\x03"},
-		"Success_ignoring_backspace": {input: "\b\b\b\n"},
+		"Success":                            {},
+		"Success_backspace":                  {input: "test\bx\n"},
+		"Success_ignoring_backspace":         {input: "\b\b\b\n"},
+		"Success_keeping_dashes":             {input: "my-secret\n"},
+		"Success_long_passphrase":            {input: strings.Repeat("squirrel-", 11) + "squirrel\n"},
+		"Success_truncating_too_long_secret": {input: strings.Repeat("0123456789", 13) + "\n"},
 
 		"Error_reading_input": {ttyReadError: true, wantErr: true},
+		"Error_on_ctrl_c":     {input: "\x03", wantErr: true, wantErrIs: context.Canceled},
 	}
 
 	for name, tc := range tests {
@@ -118,6 +158,9 @@ func TestReadSecret(t *testing.T) {
 			}()
 
 			secret, err := tt.ReadUserSecret("Enter passphrase: ")
+			if tc.wantErrIs != nil {
+				is.True(errors.Is(err, tc.wantErrIs)) // ReadUserSecret returns the expected error
+			}
 			if testutils.CheckError(is, err, tc.wantErr) {
 				return
 			}
@@ -205,6 +248,284 @@ func TestReadRecoveryKey(t *testing.T) {
 	}
 }
 
+func TestReadRecoveryKeyShare(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input        string
+		ttyReadError bool
+
+		wantErr bool
+	}{
+		"Success":                   {},
+		"Success_with_typed_hyphen": {input: "0302-8f2c-00ff\n"},
+		"Success_backspace":         {input: "0302x\b8f2c\n"},
+
+		"Error_reading_input": {ttyReadError: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			if tc.input == "" {
+				tc.input = "03028f2c00ff10429901aa557e3dc40000ee1a2b\n"
+			}
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			if tc.ttyReadError {
+				tty = nil
+			}
+
+			var out strings.Builder
+			tt := tui.New(tty, &out)
+
+			go fmt.Fprint(ptmx, tc.input)
+
+			share, err := tt.ReadRecoveryKeyShare("Enter share: ")
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			got := struct {
+				Out   string
+				Share string
+			}{
+				Out:   out.String(),
+				Share: share,
+			}
+
+			golden.CheckOrUpdate(t, got) // TestReadRecoveryKeyShare returns the expected output
+		})
+	}
+}
+
+func TestSecretSource(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+
+		wantSecret      string
+		wantRecoveryKey string
+		wantShare       string
+		wantErr         bool
+	}{
+		"Reads_one_secret_per_line":        {input: "my secret\n12345-12345\n0302-8f2c\n", wantSecret: "my secret", wantRecoveryKey: "1234512345", wantShare: "03028f2c"},
+		"Reads_last_line_without_newline":  {input: "my secret\n12345-12345\n0302-8f2c", wantSecret: "my secret", wantRecoveryKey: "1234512345", wantShare: "03028f2c"},
+		"Strips_windows_line_endings":      {input: "my secret\r\n1234512345\r\n03028f2c\r\n", wantSecret: "my secret", wantRecoveryKey: "1234512345", wantShare: "03028f2c"},
+		"Reads_empty_secret_on_empty_line": {input: "\n1234512345\n03028f2c\n", wantSecret: "", wantRecoveryKey: "1234512345", wantShare: "03028f2c"},
+
+		"Error_when_secrets_are_exhausted": {input: "my secret\n", wantSecret: "my secret", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			var out strings.Builder
+			tt := tui.New(nil, &out)
+			is.True(tt.Interactive()) // Tui is interactive by default
+
+			tt.SetSecretSource(strings.NewReader(tc.input))
+			is.True(!tt.Interactive()) // Tui is not interactive with a secret source
+
+			secret, err := tt.ReadUserSecret("Enter passphrase: ")
+			is.NoErr(err)
+			is.Equal(secret, tc.wantSecret) // ReadUserSecret returns the first secret
+
+			key, err := tt.ReadRecoveryKey()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+			is.Equal(key, tc.wantRecoveryKey) // ReadRecoveryKey returns the normalized second secret
+
+			share, err := tt.ReadRecoveryKeyShare("Enter share: ")
+			is.NoErr(err)
+			is.Equal(share, tc.wantShare) // ReadRecoveryKeyShare returns the normalized third secret
+
+			ok, err := tt.Confirm("Continue?", true)
+			is.NoErr(err)
+			is.True(ok) // Confirm returns the default answer
+
+			is.Equal(out.String(), "") // No prompt is printed
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input string
+
+		want bool
+	}{
+		"True_on_terminal":      {input: "tty", want: true},
+		"False_on_regular_file": {input: "file"},
+		"False_on_nil_file":     {input: "nil"},
+		"False_without_reader":  {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			var tt tui.Tui
+			switch tc.input {
+			case "tty":
+				ptmx, tty, err := pty.Open()
+				is.NoErr(err) // Setup: could not create fake terminal
+				defer ptmx.Close()
+				defer tty.Close()
+				tt = tui.New(tty, io.Discard)
+			case "file":
+				f, err := os.CreateTemp(t.TempDir(), "input")
+				is.NoErr(err) // Setup: could not create input file
+				defer f.Close()
+				tt = tui.New(f, io.Discard)
+			case "nil":
+				var f *os.File
+				tt = tui.New(f, io.Discard)
+			default:
+				tt = tui.New(nil, io.Discard)
+			}
+
+			is.Equal(tt.IsTerminal(), tc.want) // IsTerminal reports if the input is a terminal
+		})
+	}
+}
+
+func TestDisplayQRCode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		content string
+
+		wantLines int
+		wantErr   bool
+	}{
+		"Success_displaying_recovery_key": {content: "11272-47509-28031-54818-41671-38673-11053-06376", wantLines: 19},
+
+		"Error_when_content_is_too_long": {content: strings.Repeat("1", 8000), wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			var out strings.Builder
+			ui := tui.New(nil, &out)
+
+			lines, err := ui.DisplayQRCode(tc.content)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			is.Equal(lines, tc.wantLines)                      // DisplayQRCode returns the number of printed lines
+			is.Equal(strings.Count(out.String(), "\n"), lines) // All printed lines are counted
+
+			golden.CheckOrUpdate(t, out.String()) // TestDisplayQRCode returns the expected output
+		})
+	}
+}
+
+func TestDisplayStrength(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		entropy        uint32
+		minEntropy     uint32
+		optimalEntropy uint32
+	}{
+		"Too_weak":                 {entropy: 6, minEntropy: 13, optimalEntropy: 64},
+		"Fair":                     {entropy: 20, minEntropy: 13, optimalEntropy: 64},
+		"Good":                     {entropy: 42, minEntropy: 13, optimalEntropy: 64},
+		"Strong":                   {entropy: 64, minEntropy: 13, optimalEntropy: 64},
+		"Strong_beyond_optimal":    {entropy: 120, minEntropy: 42, optimalEntropy: 100},
+		"No_entropy":               {entropy: 0, minEntropy: 42, optimalEntropy: 100},
+		"Strong_without_optimal":   {entropy: 50, minEntropy: 42},
+		"Too_weak_without_optimal": {entropy: 20, minEntropy: 42},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var out strings.Builder
+			ui := tui.New(nil, &out)
+
+			ui.DisplayStrength(tc.entropy, tc.minEntropy, tc.optimalEntropy)
+
+			golden.CheckOrUpdate(t, out.String()) // TestDisplayStrength returns the expected output
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input        string
+		defaultYes   bool
+		ttyReadError bool
+
+		want    bool
+		wantErr bool
+	}{
+		"Yes":                          {input: "y\n", want: true},
+		"Full_yes_in_any_case":         {input: "YeS\n", want: true},
+		"No":                           {input: "n\n", defaultYes: true},
+		"Empty_answer_selects_yes":     {input: "\n", defaultYes: true, want: true},
+		"Empty_answer_selects_no":      {input: "\n"},
+		"Asks_again_on_invalid_answer": {input: "maybe\ny\n", want: true},
+
+		"Error_reading_input": {ttyReadError: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			if tc.ttyReadError {
+				tty = nil
+			}
+
+			var out strings.Builder
+			tt := tui.New(tty, &out)
+
+			go fmt.Fprint(ptmx, tc.input)
+
+			got, err := tt.Confirm("Continue?", tc.defaultYes)
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+			is.Equal(got, tc.want) // Confirm returns the expected answer
+
+			golden.CheckOrUpdate(t, out.String()) // TestConfirm prints the expected prompts
+		})
+	}
+}
+
 func getEscapes(t *testing.T) string {
 	t.Helper()
 
@@ -240,3 +561,54 @@ func (b *syncBuffer) Reset() {
 
 	b.buf.Reset()
 }
+
+func TestWaitForEnter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input        string
+		ttyReadError bool
+
+		wantErr bool
+	}{
+		"Success":                             {input: "\n"},
+		"Success_keeping_input_for_next_read": {input: "\ny\n"},
+
+		"Error_reading_input": {ttyReadError: true, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			is := is.New(t)
+
+			ptmx, tty, err := pty.Open()
+			is.NoErr(err) // Setup: could not create fake terminal
+			defer ptmx.Close()
+			defer tty.Close()
+
+			if tc.ttyReadError {
+				tty = nil
+			}
+
+			var out strings.Builder
+			tt := tui.New(tty, &out)
+
+			// All the input is written at once, so that it is read by the first read.
+			go fmt.Fprint(ptmx, tc.input)
+
+			err = tt.WaitForEnter()
+			if testutils.CheckError(is, err, tc.wantErr) {
+				return
+			}
+
+			if strings.Count(tc.input, "\n") < 2 {
+				return
+			}
+			got, err := tt.Confirm("Continue?", false)
+			is.NoErr(err) // Confirm reads the input buffered by WaitForEnter
+			is.True(got)  // Confirm returns the answer typed after Enter
+		})
+	}
+}